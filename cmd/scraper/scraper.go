@@ -1,11 +1,16 @@
 package main
 
 import (
+	"bactic/internal"
 	"bactic/internal/database"
+	"bactic/internal/scrapers"
+	_ "bactic/internal/scrapers/athnet"
+	"bactic/internal/scrapers/fetch"
 	"bactic/internal/scrapers/tfrrs"
 	"context"
 	"database/sql"
 	"flag"
+	"fmt"
 	"log"
 	"os"
 	"os/signal"
@@ -24,18 +29,72 @@ func main() {
 		dbURL        string
 		found        bool
 		verbosity    int
+		backfill     bool
+		refresh      bool
+		fetchConfig  = fetch.DefaultConfig
+		transport    *fetch.Transport
+		archiveDir   string
+		options      = make(map[string]map[string]string)
 	)
-	validScrapers := map[string](func(*sql.DB, context.Context, *sync.WaitGroup, time.Duration)){
-		"tfrrs": tfrrs.NewTFRRSScraper,
-		// "athnet": athnet.NewAthnetCollector,
-	}
 
-	flag.StringVar(&scrapersList, "scrapers", "tfrrs", "Comma-separated list of scrapers to run concurrently. Any of \"tfrrs\" and \"athnet\"")
+	flag.StringVar(&scrapersList, "scrapers", "tfrrs", fmt.Sprintf("Comma-separated list of scrapers to run concurrently. Any of %q", scrapers.Sources()))
 	flag.StringVar(&dbURL, "db", "", "Fully-qualified postgres url. Overrides the environment variable defined in DB_URL")
 	flag.IntVar(&verbosity, "verbosity", 1, "verbosity level (1, 2, 3)")
 	flag.DurationVar(&scrapeInt, "duration", time.Hour*24, "Interval between scrapes")
+	flag.BoolVar(&backfill, "backfill", false, "Scrape the full results history of each newly found athlete")
+	flag.BoolVar(&refresh, "refresh", false, "Scrape meets that were already stored again, updating their results. Recent meets are always scraped again")
+	flag.StringVar(&fetchConfig.UserAgent, "user-agent", fetchConfig.UserAgent, "User-Agent sent with every request")
+	flag.IntVar(&fetchConfig.Concurrency, "concurrency", fetchConfig.Concurrency, "Requests in flight to a site at once")
+	flag.DurationVar(&fetchConfig.Delay, "delay", fetchConfig.Delay, "Least time between two requests to a site")
+	flag.IntVar(&fetchConfig.Retries, "retries", fetchConfig.Retries, "Times a failed request, or one answered with 429 or 5xx, is retried")
+	flag.DurationVar(&fetchConfig.Backoff, "backoff", fetchConfig.Backoff, "Wait before the first retry of a request, doubling with each retry after")
+	flag.BoolVar(&fetchConfig.IgnoreRobots, "ignore-robots", false, "Fetch pages that robots.txt disallows")
+	flag.Func("option", "Option of a single scraper, as scraper.key=value, such as tfrrs.feed=https://www.tfrrs.org/results.rss or athnet.teams=1501,1502. May be repeated", func(option string) error {
+		name, keyValue, found := strings.Cut(option, ".")
+		key, value, hasValue := strings.Cut(keyValue, "=")
+		if !found || !hasValue {
+			return fmt.Errorf("option %s is not of the form scraper.key=value", option)
+		}
+		if options[name] == nil {
+			options[name] = make(map[string]string)
+		}
+		options[name][key] = value
+		return nil
+	})
+	flag.StringVar(&archiveDir, "archive", "", "Directory every fetched page is archived in. Pages are not archived if empty")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [command]\n\nCommands:\n", os.Args[0])
+		fmt.Fprintln(flag.CommandLine.Output(), "  scrape\t\t\tRun the scrapers on an interval (default)")
+		fmt.Fprintln(flag.CommandLine.Output(), "  backfill [-from date] [-to date] [-season season] [-meets ids]\tScrape past meets listed between two dates or given by ID, resuming where an interrupted backfill stopped")
+		fmt.Fprintln(flag.CommandLine.Output(), "  reparse [-rebuild] [-meets ids] [-match text]\tParse the meets in the archive again without fetching them, optionally rebuilding the database from scratch")
+		fmt.Fprintln(flag.CommandLine.Output(), "  backfill-seasons\tInfer the season of every stored meet, correcting those stored under the wrong season")
+		fmt.Fprintln(flag.CommandLine.Output(), "\nFlags:")
+		flag.PrintDefaults()
+	}
 	flag.Parse()
 
+	command := "scrape"
+	if flag.NArg() > 0 {
+		command = flag.Arg(0)
+	}
+
+	if len(archiveDir) > 0 {
+		archive, err := fetch.OpenArchive(archiveDir)
+		if err != nil {
+			log.Fatalf("Unable to open page archive %s: %v", archiveDir, err)
+		}
+		fetchConfig.Archive = archive
+	}
+	// reparsing replays archived pages in place of the network
+	if command == "reparse" {
+		if fetchConfig.Archive == nil {
+			log.Fatal("The reparse command needs an archive to replay from, given by the arg \"archive\"")
+		}
+		fetchConfig.Replay = true
+	}
+	// every scraper shares one transport, so that the limits hold across them
+	transport = fetch.NewTransport(fetchConfig)
+
 	log.SetPrefix("Scraper main")
 
 	if len(dbURL) == 0 {
@@ -49,14 +108,33 @@ func main() {
 	database.SetupSchema(db)
 	defer db.Close()
 
-	var scraperSet []func(*sql.DB, context.Context, *sync.WaitGroup, time.Duration)
+	switch command {
+	case "scrape":
+		scrape(db, scrapersList, scrapeInt, func(name string) scrapers.Config {
+			return scrapers.Config{Transport: transport, Refresh: refresh, Backfill: backfill, Options: options[name]}
+		})
+	case "backfill":
+		backfillMeets(db, flag.Args()[1:], tfrrs.Config{Backfill: backfill, Refresh: refresh, Transport: transport})
+	case "reparse":
+		reparseMeets(db, flag.Args()[1:], tfrrs.Config{Transport: transport})
+	case "backfill-seasons":
+		backfillSeasons(db)
+	default:
+		flag.Usage()
+		log.Fatalf("Passed illegal command %s", command)
+	}
+}
 
-	for _, s := range strings.Split(scrapersList, ",") {
-		scraper, found := validScrapers[s]
-		if !found {
-			log.Fatalf("Passed illegal scraper name %s", s)
+// Run the listed scrapers every scrapeInt until interrupted
+func scrape(db *sql.DB, scrapersList string, scrapeInt time.Duration, config func(name string) scrapers.Config) {
+	names := strings.Split(scrapersList, ",")
+	sources := make([]scrapers.Source, len(names))
+	for i, name := range names {
+		source, err := scrapers.New(name, config(name))
+		if err != nil {
+			log.Fatalf("Unable to create scraper %s: %v", name, err)
 		}
-		scraperSet = append(scraperSet, scraper)
+		sources[i] = source
 	}
 
 	interrupt := make(chan os.Signal, 1)
@@ -64,9 +142,9 @@ func main() {
 	signal.Notify(interrupt, syscall.SIGINT)
 	var wg sync.WaitGroup
 
-	for _, startScraper := range scraperSet {
+	for i, source := range sources {
 		wg.Add(1)
-		go startScraper(db, ctx, &wg, scrapeInt)
+		go scrapers.Run(db, ctx, &wg, names[i], source, scrapeInt, config(names[i]).Refresh)
 	}
 
 	go func() {
@@ -77,5 +155,116 @@ func main() {
 
 	wg.Wait()
 	log.Println("All scrapers stopped, closing database...")
-	db.Close()
+}
+
+// Seasons accepted by the backfill command
+var seasonNames = map[string]int{
+	"any":     -1,
+	"xc":      internal.XC,
+	"indoor":  internal.INDOOR,
+	"outdoor": internal.OUTDOOR,
+}
+
+// Scrape the historical meets selected by the backfill command's args until done or interrupted
+func backfillMeets(db *sql.DB, args []string, config tfrrs.Config) {
+	var (
+		from, to, season, meets string
+		options                 tfrrs.BackfillOptions
+		err                     error
+	)
+	backfillFlags := flag.NewFlagSet("backfill", flag.ExitOnError)
+	backfillFlags.StringVar(&from, "from", "", "First date of meets to backfill, as YYYY-MM-DD")
+	backfillFlags.StringVar(&to, "to", "", "Last date of meets to backfill, as YYYY-MM-DD. Defaults to today")
+	backfillFlags.StringVar(&season, "season", "any", "Season of meets to backfill. Any of \"any\", \"xc\", \"indoor\" and \"outdoor\"")
+	backfillFlags.StringVar(&meets, "meets", "", "Comma-separated list of tfrrs meet IDs to backfill, prefixed with xc/ for cross country meets")
+	backfillFlags.Parse(args)
+
+	if len(from) > 0 {
+		if options.From, err = time.Parse(time.DateOnly, from); err != nil {
+			log.Fatalf("Passed illegal from date %s", from)
+		}
+		options.To = time.Now().UTC()
+		if len(to) > 0 {
+			if options.To, err = time.Parse(time.DateOnly, to); err != nil {
+				log.Fatalf("Passed illegal to date %s", to)
+			}
+		}
+	}
+	var found bool
+	if options.Season, found = seasonNames[season]; !found {
+		log.Fatalf("Passed illegal season %s", season)
+	}
+	if len(meets) > 0 {
+		options.MeetIDs = strings.Split(meets, ",")
+	}
+	if options.From.IsZero() && len(options.MeetIDs) == 0 {
+		backfillFlags.Usage()
+		log.Fatal("Backfill needs a from date or a list of meet IDs")
+	}
+
+	interrupt := make(chan os.Signal, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	signal.Notify(interrupt, syscall.SIGINT)
+	go func() {
+		<-interrupt
+		log.Println("Received interrupt signal, stopping backfill...")
+		cancel()
+	}()
+
+	tfrrs.Backfill(db, ctx, options, config)
+	log.Println("Backfill stopped, closing database...")
+}
+
+// Parse the archived meets selected by the reparse command's args again, until done or interrupted
+func reparseMeets(db *sql.DB, args []string, config tfrrs.Config) {
+	var (
+		rebuild bool
+		meets   string
+		options tfrrs.ReparseOptions
+	)
+	reparseFlags := flag.NewFlagSet("reparse", flag.ExitOnError)
+	reparseFlags.BoolVar(&rebuild, "rebuild", false, "Drop every table and rebuild the database from the archive alone")
+	reparseFlags.StringVar(&meets, "meets", "", "Comma-separated list of tfrrs meet IDs to reparse, prefixed with xc/ for cross country meets")
+	reparseFlags.StringVar(&options.Match, "match", "", "Only reparse meets whose page contains this text, such as the title of a newly parsed event")
+	reparseFlags.Parse(args)
+	if len(meets) > 0 {
+		options.MeetIDs = strings.Split(meets, ",")
+	}
+
+	if rebuild {
+		log.Println("Dropping every table to rebuild the database from the archive...")
+		database.TeardownSchema(db)
+		database.SetupSchema(db)
+	}
+
+	interrupt := make(chan os.Signal, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	signal.Notify(interrupt, syscall.SIGINT)
+	go func() {
+		<-interrupt
+		log.Println("Received interrupt signal, stopping reparse...")
+		cancel()
+	}()
+
+	if err := tfrrs.Reparse(db, ctx, options, config); err != nil {
+		log.Fatal(err)
+	}
+	log.Println("Reparse stopped, closing database...")
+}
+
+// Correct the season of meets stored before it was inferred
+func backfillSeasons(db *sql.DB) {
+	tx, err := db.Begin()
+	if err != nil {
+		log.Fatal(err)
+	}
+	corrected, err := database.BackfillSeasons(tx)
+	if err != nil {
+		tx.Rollback()
+		log.Fatal(err)
+	}
+	if err = tx.Commit(); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Corrected the season of %d meets", corrected)
 }
@@ -5,10 +5,14 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"sort"
+	"strconv"
+	"strings"
 
 	_ "embed"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 //go:embed sql/schema.sql
@@ -70,7 +74,7 @@ func getAthlete(tx *sql.Tx, athID uint32) (internal.Athlete, bool) {
 	var schools []uint32
 	var school uint32
 	for rows.Next() {
-		err = row.Scan(&school)
+		err = rows.Scan(&school)
 		if err == sql.ErrNoRows {
 			break
 		} else if err != nil {
@@ -152,7 +156,13 @@ func GetSchoolURL(tx *sql.Tx, schoolURL string) (internal.School, bool) {
 
 func InsertAthlete(tx *sql.Tx, ath internal.Athlete) error {
 	// We assume that the athlete's id has already been populated by the tfrrs id
-	_, err := tx.Exec("INSERT INTO athlete(id, name) VALUES($1, $2)", ath.ID, ath.Name)
+	_, err := tx.Exec("INSERT INTO athlete(id, name, year, redshirt, eligibility, hometown) VALUES($1, $2, $3, $4, $5, $6)",
+		ath.ID,
+		ath.Name,
+		sql.NullInt32{Int32: int32(ath.Year), Valid: ath.Year != internal.UNKNOWN_CLASS},
+		ath.Redshirt,
+		sql.NullInt32{Int32: int32(ath.Eligibility), Valid: ath.Eligibility > 0},
+		nullString(ath.Hometown))
 	if err != nil {
 		return err
 	}
@@ -162,14 +172,53 @@ func InsertAthlete(tx *sql.Tx, ath internal.Athlete) error {
 			return fmt.Errorf("could not create athlete school relation: %s", err)
 		}
 	}
+	for _, roster := range ath.Rosters {
+		if err = InsertRoster(tx, roster); err != nil {
+			return fmt.Errorf("could not create athlete roster: %s", err)
+		}
+	}
 	return nil
 }
 
+// Add an athlete to a school's roster for a season, keeping the roster already recorded
+func InsertRoster(tx *sql.Tx, roster internal.Roster) error {
+	_, err := tx.Exec(`INSERT INTO roster(athlete_id, school_id, year, season, class_year) VALUES($1, $2, $3, $4, $5)
+        ON CONFLICT DO NOTHING`,
+		roster.AthleteID,
+		roster.SchoolID,
+		roster.Year,
+		roster.Season,
+		sql.NullInt32{Int32: int32(roster.ClassYear), Valid: roster.ClassYear != internal.UNKNOWN_CLASS})
+	return err
+}
+
+// An athlete's rosters ordered by season
+func GetRosters(tx *sql.Tx, athID uint32) []internal.Roster {
+	rows, err := tx.Query(`SELECT athlete_id, school_id, year, season, COALESCE(class_year, 0) FROM roster WHERE athlete_id = $1
+        ORDER BY year, season`, athID)
+	if err != nil {
+		panic(err)
+	}
+
+	var rosters []internal.Roster
+	for rows.Next() {
+		var roster internal.Roster
+		if err = rows.Scan(&roster.AthleteID, &roster.SchoolID, &roster.Year, &roster.Season, &roster.ClassYear); err != nil {
+			panic(err)
+		}
+		rosters = append(rosters, roster)
+	}
+	if err = rows.Close(); err != nil {
+		panic(err)
+	}
+	return rosters
+}
+
 // Get athlete struct from database according to bactic athlete id
 func GetAthlete(tx *sql.Tx, athID uint32) (internal.Athlete, bool) {
-	row := tx.QueryRow("SELECT name FROM athlete WHERE id = $1", athID)
+	row := tx.QueryRow("SELECT name, COALESCE(year, 0), redshirt, COALESCE(eligibility, 0), COALESCE(hometown, '') FROM athlete WHERE id = $1", athID)
 	var ath internal.Athlete
-	err := row.Scan(&ath.Name)
+	err := row.Scan(&ath.Name, &ath.Year, &ath.Redshirt, &ath.Eligibility, &ath.Hometown)
 	if err == sql.ErrNoRows {
 		return ath, false
 	} else if err != nil {
@@ -177,7 +226,21 @@ func GetAthlete(tx *sql.Tx, athID uint32) (internal.Athlete, bool) {
 	}
 
 	ath.ID = athID
-	// TODO: get other information about athelete (year, schools)
+	rows, err := tx.Query("SELECT school_id FROM athlete_in_school WHERE athlete_id = $1", athID)
+	if err != nil {
+		panic(err)
+	}
+	for rows.Next() {
+		var school uint32
+		if err = rows.Scan(&school); err != nil {
+			panic(err)
+		}
+		ath.Schools = append(ath.Schools, school)
+	}
+	if err = rows.Close(); err != nil {
+		panic(err)
+	}
+	ath.Rosters = GetRosters(tx, athID)
 	return ath, true
 }
 
@@ -206,40 +269,477 @@ func InsertSchool(tx *sql.Tx, school internal.School) error {
 	return nil
 }
 
+// Relay results have no single athlete, and legs we could not resolve have no athlete, so zero ids are stored as null
+func nullID(id uint32) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(id), Valid: id != 0}
+}
+
+// Wind readings are not recorded for every event, so a missing reading is stored as null
+func nullWind(wind *float32) sql.NullFloat64 {
+	if wind == nil {
+		return sql.NullFloat64{}
+	}
+	return sql.NullFloat64{Float64: float64(*wind), Valid: true}
+}
+
+// Values of a result that corrections are logged for, in the order of resultValues followed by resultDetails
+var resultFields = []string{"ath_id", "pl", "quant", "wind_ms", "status", "dq_code", "timing", "legs", "attempts", "heights",
+	"components"}
+
+// Tables of the rows a result holds besides its own, in the order of resultDetails
+var resultDetailTables = []string{"relay_leg", "attempt", "height_attempt", "combined_component"}
+
+// Quantities are scraped as float32, so they are logged at that precision
+func floatText(f sql.NullFloat64) sql.NullString {
+	return sql.NullString{String: strconv.FormatFloat(f.Float64, 'f', -1, 32), Valid: f.Valid}
+}
+
+func intText(i sql.NullInt64) sql.NullString {
+	return sql.NullString{String: strconv.FormatInt(i.Int64, 10), Valid: i.Valid}
+}
+
+// Text of a result's values, as logged in its change history
+func resultValues(athleteID sql.NullInt64, place int, quantity sql.NullFloat64, wind sql.NullFloat64, status int, dqCode sql.NullString, timing int) []sql.NullString {
+	return []sql.NullString{
+		intText(athleteID),
+		{String: strconv.Itoa(place), Valid: true},
+		floatText(quantity),
+		floatText(wind),
+		{String: strconv.Itoa(status), Valid: true},
+		dqCode,
+		{String: strconv.Itoa(timing), Valid: true},
+	}
+}
+
+// Text of one row a result holds in another table, its columns separated by colons and nulls left empty
+func detailText(columns ...sql.NullString) string {
+	text := make([]string, len(columns))
+	for i, column := range columns {
+		text[i] = column.String
+	}
+	return strings.Join(text, ":")
+}
+
+// Text of the rows a result holds in one of resultDetailTables, in key order, null if it holds none
+func detailsText(rows []string) sql.NullString {
+	return sql.NullString{String: strings.Join(rows, " "), Valid: len(rows) > 0}
+}
+
+func legText(leg int, athleteID sql.NullInt64, split sql.NullFloat64) string {
+	return detailText(intText(sql.NullInt64{Int64: int64(leg), Valid: true}), intText(athleteID), floatText(split))
+}
+
+func attemptText(number int, quantity sql.NullFloat64, wind sql.NullFloat64, status int) string {
+	return detailText(intText(sql.NullInt64{Int64: int64(number), Valid: true}), floatText(quantity), floatText(wind),
+		intText(sql.NullInt64{Int64: int64(status), Valid: true}))
+}
+
+func heightText(height float64, attempts string) string {
+	return detailText(floatText(sql.NullFloat64{Float64: height, Valid: true}), sql.NullString{String: attempts, Valid: true})
+}
+
+func componentText(eventType int, quantity sql.NullFloat64, points int, computedPoints int, status int) string {
+	return detailText(intText(sql.NullInt64{Int64: int64(eventType), Valid: true}), floatText(quantity),
+		intText(sql.NullInt64{Int64: int64(points), Valid: true}), intText(sql.NullInt64{Int64: int64(computedPoints), Valid: true}),
+		intText(sql.NullInt64{Int64: int64(status), Valid: true}))
+}
+
+// Text of the relay legs, attempts, heights and combined event components of a scraped result, as logged in its change
+// history
+func resultDetails(result internal.Result) []sql.NullString {
+	var legs, attempts, heights, components []string
+	for i, member := range result.Members {
+		var split sql.NullFloat64
+		if i < len(result.Splits) {
+			split = sql.NullFloat64{Float64: float64(result.Splits[i]), Valid: true}
+		}
+		legs = append(legs, legText(i+1, nullID(member), split))
+	}
+
+	// rows are listed in the order they are read back in by storedResultDetails
+	sorted := append([]internal.Attempt(nil), result.Attempts...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Number < sorted[j].Number })
+	for _, attempt := range sorted {
+		quantity := sql.NullFloat64{Float64: float64(attempt.Quantity), Valid: attempt.Status == internal.VALID}
+		attempts = append(attempts, attemptText(attempt.Number, quantity, nullWind(attempt.WindMS), attempt.Status))
+	}
+	sortedHeights := append([]internal.HeightAttempt(nil), result.Heights...)
+	sort.SliceStable(sortedHeights, func(i, j int) bool { return sortedHeights[i].Height < sortedHeights[j].Height })
+	for _, height := range sortedHeights {
+		heights = append(heights, heightText(float64(height.Height), height.Attempts))
+	}
+	sortedComponents := append([]internal.CombinedComponent(nil), result.Components...)
+	sort.SliceStable(sortedComponents, func(i, j int) bool { return sortedComponents[i].Type < sortedComponents[j].Type })
+	for _, component := range sortedComponents {
+		quantity := sql.NullFloat64{Float64: float64(component.Quantity), Valid: component.Status == internal.VALID}
+		components = append(components, componentText(int(component.Type), quantity, component.Points, component.ComputedPoints,
+			component.Status))
+	}
+	return []sql.NullString{detailsText(legs), detailsText(attempts), detailsText(heights), detailsText(components)}
+}
+
+// Text of the relay legs, attempts, heights and combined event components stored for a result, as logged in its change
+// history
+func storedResultDetails(tx *sql.Tx, resultID uint32) ([]sql.NullString, error) {
+	queries := []string{
+		"SELECT leg, ath_id, split FROM relay_leg WHERE result_id = $1 ORDER BY leg",
+		"SELECT num, quant, wind_ms, status FROM attempt WHERE result_id = $1 ORDER BY num",
+		"SELECT height, attempts FROM height_attempt WHERE result_id = $1 ORDER BY height",
+		"SELECT event_type, quant, points, computed_points, status FROM combined_component WHERE result_id = $1 ORDER BY event_type",
+	}
+	details := make([]sql.NullString, len(queries))
+	for i, query := range queries {
+		rows, err := tx.Query(query, resultID)
+		if err != nil {
+			return nil, err
+		}
+		var text []string
+		for rows.Next() {
+			var (
+				key, status, points, computedPoints int
+				athleteID                           sql.NullInt64
+				quantity, wind                      sql.NullFloat64
+				height                              float64
+				attempts                            string
+			)
+			switch i {
+			case 0:
+				err = rows.Scan(&key, &athleteID, &quantity)
+				text = append(text, legText(key, athleteID, quantity))
+			case 1:
+				err = rows.Scan(&key, &quantity, &wind, &status)
+				text = append(text, attemptText(key, quantity, wind, status))
+			case 2:
+				err = rows.Scan(&height, &attempts)
+				text = append(text, heightText(height, attempts))
+			case 3:
+				err = rows.Scan(&key, &quantity, &points, &computedPoints, &status)
+				text = append(text, componentText(key, quantity, points, computedPoints, status))
+			}
+			if err != nil {
+				rows.Close()
+				return nil, err
+			}
+		}
+		if err := rows.Close(); err != nil {
+			return nil, err
+		}
+		details[i] = detailsText(text)
+	}
+	return details, nil
+}
+
+// Delete the rows a result holds in resultDetailTables
+func deleteResultDetails(tx *sql.Tx, resultID uint32) error {
+	for _, table := range resultDetailTables {
+		if _, err := tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE result_id = $1", table), resultID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Log the values of a result that changed in a version
+func insertResultChanges(tx *sql.Tx, resultID uint32, version int, old []sql.NullString, new []sql.NullString) error {
+	for i, field := range resultFields {
+		if old[i] == new[i] {
+			continue
+		}
+		_, err := tx.Exec("INSERT INTO result_change(result_id, version, field, old_value, new_value) VALUES($1, $2, $3, $4, $5)",
+			resultID, version, field, old[i], new[i])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Insert a result, or apply a correction to the athlete's (or relay team's) result in the heat if it was already scraped.
+// A correction bumps the result's version, logs the values it changed and replaces the result's legs, attempts, heights
+// and components
 func insertResult(tx *sql.Tx, result internal.Result) error {
-	id := uuid.New().ID()
-	result.ID = id
-	_, err := tx.Exec(`INSERT INTO result(id, heat_id, ath_id, pl, 
-        quant, wind_ms, stage) VALUES($1, $2, $3, $4, $5, $6, $7)`,
-		result.ID,
-		result.HeatID,
-		result.AthleteID,
-		result.Place,
-		result.Quantity,
-		result.WindMS,
-		result.Stage)
+	// results without a valid mark (fouls, no-heights, DNFs, etc) are stored with a null quantity
+	quantity := sql.NullFloat64{Float64: float64(result.Quantity), Valid: result.Status == internal.VALID}
+	dqCode := sql.NullString{String: result.DQCode, Valid: len(result.DQCode) > 0}
+	values := append(resultValues(nullID(result.AthleteID), result.Place, quantity, nullWind(result.WindMS), result.Status, dqCode,
+		result.Timing), resultDetails(result)...)
+
+	var (
+		storedAthlete           sql.NullInt64
+		place, status, timing   int
+		storedQuant, storedWind sql.NullFloat64
+		storedDQCode            sql.NullString
+	)
+	err := tx.QueryRow(`SELECT id, version, ath_id, pl, quant, wind_ms, status, dq_code, timing FROM result WHERE heat_id = $1
+        AND COALESCE(ath_id, 0) = $2 AND COALESCE(school_id, 0) = $3 AND COALESCE(team, '') = $4`,
+		result.HeatID, result.AthleteID, result.SchoolID, result.Team).Scan(
+		&result.ID, &result.Version, &storedAthlete, &place, &storedQuant, &storedWind, &status, &storedDQCode, &timing)
+	if err == sql.ErrNoRows && result.AthleteID != 0 {
+		// a row the source re-linked to another athlete is found by its mark instead, as long as no other row of the heat
+		// not yet re-scraped shares it
+		var candidates int
+		err = tx.QueryRow(`SELECT id, version, ath_id, pl, quant, wind_ms, status, dq_code, timing, COUNT(*) OVER () FROM result
+            WHERE heat_id = $1 AND ath_id IS NOT NULL AND COALESCE(school_id, 0) = $2 AND COALESCE(team, '') = $3
+            AND quant IS NOT DISTINCT FROM $4 AND status = $5 AND scraped < NOW() LIMIT 1`,
+			result.HeatID, result.SchoolID, result.Team, quantity, result.Status).Scan(
+			&result.ID, &result.Version, &storedAthlete, &place, &storedQuant, &storedWind, &status, &storedDQCode, &timing, &candidates)
+		if err == nil && candidates > 1 {
+			err = sql.ErrNoRows
+		}
+	}
+	switch {
+	case err == sql.ErrNoRows:
+		result.ID = uuid.New().ID()
+		result.Version = 1
+		_, err = tx.Exec(`INSERT INTO result(id, heat_id, ath_id, pl, 
+            quant, wind_ms, aided, stage, status, dq_code, timing, school_id, team) VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`,
+			result.ID,
+			result.HeatID,
+			nullID(result.AthleteID),
+			result.Place,
+			quantity,
+			nullWind(result.WindMS),
+			result.WindAided(),
+			result.Stage,
+			result.Status,
+			dqCode,
+			result.Timing,
+			nullID(result.SchoolID),
+			sql.NullString{String: result.Team, Valid: len(result.Team) > 0})
+	case err == nil:
+		var details []sql.NullString
+		if details, err = storedResultDetails(tx, result.ID); err != nil {
+			return err
+		}
+		stored := append(resultValues(storedAthlete, place, storedQuant, storedWind, status, storedDQCode, timing), details...)
+		for i := range stored {
+			if stored[i] != values[i] {
+				result.Version++
+				if err = insertResultChanges(tx, result.ID, result.Version, stored, values); err != nil {
+					return err
+				}
+				break
+			}
+		}
+		_, err = tx.Exec(`UPDATE result SET ath_id = $2, pl = $3, quant = $4, wind_ms = $5, aided = $6, stage = $7, status = $8,
+            dq_code = $9, timing = $10, version = $11, scraped = NOW() WHERE id = $1`,
+			result.ID,
+			nullID(result.AthleteID),
+			result.Place,
+			quantity,
+			nullWind(result.WindMS),
+			result.WindAided(),
+			result.Stage,
+			result.Status,
+			dqCode,
+			result.Timing,
+			result.Version)
+		if err == nil {
+			// the scraped legs, attempts, heights and components replace the stored ones, dropping any no longer listed
+			err = deleteResultDetails(tx, result.ID)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	for i, member := range result.Members {
+		var split sql.NullFloat64
+		if i < len(result.Splits) {
+			split = sql.NullFloat64{Float64: float64(result.Splits[i]), Valid: true}
+		}
+		_, err := tx.Exec(`INSERT INTO relay_leg(result_id, leg, ath_id, split) VALUES($1, $2, $3, $4)
+            ON CONFLICT (result_id, leg) DO UPDATE SET ath_id = EXCLUDED.ath_id, split = EXCLUDED.split`, result.ID, i+1, nullID(member), split)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, attempt := range result.Attempts {
+		attempt.ResultID = result.ID
+		if err := insertAttempt(tx, attempt); err != nil {
+			return err
+		}
+	}
+	for _, height := range result.Heights {
+		height.ResultID = result.ID
+		if err := insertHeightAttempt(tx, height); err != nil {
+			return err
+		}
+	}
+	for _, component := range result.Components {
+		component.ResultID = result.ID
+		if err := insertCombinedComponent(tx, component); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func insertCombinedComponent(tx *sql.Tx, component internal.CombinedComponent) error {
+	quantity := sql.NullFloat64{Float64: float64(component.Quantity), Valid: component.Status == internal.VALID}
+	_, err := tx.Exec(`INSERT INTO combined_component(result_id, event_type, quant, points, 
+        computed_points, status) VALUES($1, $2, $3, $4, $5, $6) ON CONFLICT (result_id, event_type) DO UPDATE SET quant = EXCLUDED.quant,
+        points = EXCLUDED.points, computed_points = EXCLUDED.computed_points, status = EXCLUDED.status`,
+		component.ResultID,
+		component.Type,
+		quantity,
+		component.Points,
+		component.ComputedPoints,
+		component.Status)
+	return err
+}
+
+func insertHeightAttempt(tx *sql.Tx, height internal.HeightAttempt) error {
+	_, err := tx.Exec(`INSERT INTO height_attempt(result_id, height, attempts) VALUES($1, $2, $3)
+        ON CONFLICT (result_id, height) DO UPDATE SET attempts = EXCLUDED.attempts`, height.ResultID, height.Height, height.Attempts)
 	return err
 }
 
-// We should process inserts heat-by-heat, since that is how the data is scraped
-func InsertHeat(tx *sql.Tx, eventType internal.EventType, meetID uint32, results []internal.Result) (uint32, error) {
-	// check to see if athlete exists
-	heatID := uuid.New().ID()
-	_, err := tx.Exec("INSERT INTO heat(id, meet_id, event_type) VALUES($1, $2, $3)", heatID, meetID, eventType)
+func insertAttempt(tx *sql.Tx, attempt internal.Attempt) error {
+	quantity := sql.NullFloat64{Float64: float64(attempt.Quantity), Valid: attempt.Status == internal.VALID}
+	_, err := tx.Exec(`INSERT INTO attempt(result_id, num, quant, wind_ms, status) VALUES($1, $2, $3, $4, $5)
+        ON CONFLICT (result_id, num) DO UPDATE SET quant = EXCLUDED.quant, wind_ms = EXCLUDED.wind_ms, status = EXCLUDED.status`,
+		attempt.ResultID,
+		attempt.Number,
+		quantity,
+		nullWind(attempt.WindMS),
+		attempt.Status)
+	return err
+}
+
+// Remove the results of a re-scraped meet that its page no longer lists, such as those of an athlete since scratched
+// from a heat, logging their values and rows in other tables as changed to null. Nothing is removed unless results of the meet were scraped in this
+// transaction, so that a failed visit does not clear the meet
+func RemoveStaleResults(tx *sql.Tx, meetID uint32) (int, error) {
+	rows, err := tx.Query(`SELECT r.id, r.version, r.ath_id, r.pl, r.quant, r.wind_ms, r.status, r.dq_code, r.timing FROM result r
+        JOIN heat h ON r.heat_id = h.id WHERE h.meet_id = $1 AND r.scraped < NOW() AND EXISTS (SELECT 1 FROM result s
+        JOIN heat sh ON s.heat_id = sh.id WHERE sh.meet_id = $1 AND s.scraped = NOW())`, meetID)
 	if err != nil {
 		return 0, err
 	}
+	type staleResult struct {
+		id      uint32
+		version int
+		values  []sql.NullString
+	}
+	var stale []staleResult
+	for rows.Next() {
+		var (
+			result                staleResult
+			athleteID             sql.NullInt64
+			place, status, timing int
+			quant, wind           sql.NullFloat64
+			dqCode                sql.NullString
+		)
+		if err := rows.Scan(&result.id, &result.version, &athleteID, &place, &quant, &wind, &status, &dqCode, &timing); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		result.values = resultValues(athleteID, place, quant, wind, status, dqCode, timing)
+		stale = append(stale, result)
+	}
+	if err := rows.Close(); err != nil {
+		return 0, err
+	}
+
+	for _, result := range stale {
+		details, err := storedResultDetails(tx, result.id)
+		if err != nil {
+			return 0, err
+		}
+		values := append(result.values, details...)
+		if err := insertResultChanges(tx, result.id, result.version+1, values, make([]sql.NullString, len(resultFields))); err != nil {
+			return 0, err
+		}
+		if err := deleteResultDetails(tx, result.id); err != nil {
+			return 0, err
+		}
+		if _, err := tx.Exec("DELETE FROM result WHERE id = $1", result.id); err != nil {
+			return 0, err
+		}
+	}
+	return len(stale), nil
+}
 
-	// check to see that all schools are in the database
+// Change history of a result, oldest first
+func GetResultChanges(tx *sql.Tx, resultID uint32) []internal.ResultChange {
+	rows, err := tx.Query(`SELECT result_id, version, field, COALESCE(old_value, ''), COALESCE(new_value, ''), changed FROM result_change
+        WHERE result_id = $1 ORDER BY version, field`, resultID)
+	if err != nil {
+		panic(err)
+	}
+	defer rows.Close()
 
+	var changes []internal.ResultChange
+	for rows.Next() {
+		var change internal.ResultChange
+		if err := rows.Scan(&change.ResultID, &change.Version, &change.Field, &change.Old, &change.New, &change.Changed); err != nil {
+			panic(err)
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// We should process inserts heat-by-heat, since that is how the data is scraped. A heat already stored for the
+// meet is updated and returned, so that a meet can be scraped again without duplicating it
+func InsertHeat(tx *sql.Tx, heat internal.Heat, results []internal.Result) (uint32, error) {
+	var heatID uint32
+	err := tx.QueryRow(`INSERT INTO heat(id, meet_id, event_type, sex, stage, heat_num, section, wind_ms, date) VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9)
+        ON CONFLICT (meet_id, event_type, sex, stage, heat_num, section) DO UPDATE SET wind_ms = EXCLUDED.wind_ms, date = EXCLUDED.date RETURNING id`,
+		uuid.New().ID(), heat.MeetID, heat.Type, heat.Sex, heat.Stage, heat.Number, heat.Section, nullWind(heat.WindMS),
+		sql.NullTime{Time: heat.Date, Valid: !heat.Date.IsZero()}).Scan(&heatID)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := InsertResults(tx, heatID, results); err != nil {
+		return 0, err
+	}
+	return heatID, nil
+}
+
+// Add results to an existing heat
+func InsertResults(tx *sql.Tx, heatID uint32, results []internal.Result) error {
 	for _, result := range results {
 		result.HeatID = heatID
 		if err := insertResult(tx, result); err != nil {
-			return 0, err
+			return err
 		}
 	}
+	return nil
+}
 
-	return heatID, nil
+// Add team standings to an existing heat
+func InsertTeamResults(tx *sql.Tx, heatID uint32, results []internal.TeamResult) error {
+	for _, result := range results {
+		result.ID = uuid.New().ID()
+		result.HeatID = heatID
+		_, err := tx.Exec(`INSERT INTO team_result(id, heat_id, school_id, pl, score, scorers, 
+            displacers, total_time, avg_time, spread) VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) ON CONFLICT (heat_id, school_id)
+            DO UPDATE SET pl = EXCLUDED.pl, score = EXCLUDED.score, scorers = EXCLUDED.scorers, displacers = EXCLUDED.displacers,
+            total_time = EXCLUDED.total_time, avg_time = EXCLUDED.avg_time, spread = EXCLUDED.spread`,
+			result.ID,
+			result.HeatID,
+			result.SchoolID,
+			result.Place,
+			result.Score,
+			pq.Array(result.Scorers),
+			pq.Array(result.Displacers),
+			nullTime(result.TotalTime),
+			nullTime(result.AvgTime),
+			nullTime(result.Spread))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Team times are only listed by some meets, so a zero time is stored as null
+func nullTime(t float32) sql.NullFloat64 {
+	return sql.NullFloat64{Float64: float64(t), Valid: t != 0}
 }
 
 // Query the athlete map table for an id relation
@@ -273,11 +773,204 @@ func AddAthleteRelation(tx *sql.Tx, x uint32, y uint32) error {
 	return err
 }
 
+// Return the meet stored for a source's key, such as the tfrrs meet ID, so that meets are only created once
+func GetMeetRelation(tx *sql.Tx, key string) (uint32, bool) {
+	var meetID uint32
+	err := tx.QueryRow("SELECT meet_id FROM meet_map WHERE key = $1", key).Scan(&meetID)
+	if err == sql.ErrNoRows {
+		return 0, false
+	} else if err != nil {
+		panic(err)
+	}
+	return meetID, true
+}
+
+func AddMeetRelation(tx *sql.Tx, key string, meetID uint32) error {
+	_, err := tx.Exec("INSERT INTO meet_map(key, meet_id) VALUES($1, $2)", key, meetID)
+	return err
+}
+
+// Return the athlete stored for a source's athlete key, such as athnet/12345678, for sources with their own athlete IDs
+func GetAthleteSourceRelation(tx *sql.Tx, key string) (uint32, bool) {
+	var athleteID uint32
+	err := tx.QueryRow("SELECT athlete_id FROM athlete_source_map WHERE key = $1", key).Scan(&athleteID)
+	if err == sql.ErrNoRows {
+		return 0, false
+	} else if err != nil {
+		panic(err)
+	}
+	return athleteID, true
+}
+
+func AddAthleteSourceRelation(tx *sql.Tx, key string, athleteID uint32) error {
+	_, err := tx.Exec("INSERT INTO athlete_source_map(key, athlete_id) VALUES($1, $2)", key, athleteID)
+	return err
+}
+
+// Whether a step of a backfill, such as a month of meet listings, was completed by an earlier run
+func GetBackfillProgress(tx *sql.Tx, key string) bool {
+	var completed int
+	err := tx.QueryRow("SELECT 1 FROM backfill_progress WHERE key = $1", key).Scan(&completed)
+	if err == sql.ErrNoRows {
+		return false
+	} else if err != nil {
+		panic(err)
+	}
+	return true
+}
+
+// Record a step of a backfill as completed so that later runs resume after it
+func SetBackfillProgress(tx *sql.Tx, key string) error {
+	_, err := tx.Exec("INSERT INTO backfill_progress(key) VALUES($1) ON CONFLICT DO NOTHING", key)
+	return err
+}
+
+// Header details of a meet are often unlisted, so empty strings are stored as null
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: len(s) > 0}
+}
+
+func nullEndDate(meet internal.Meet) sql.NullTime {
+	return sql.NullTime{Time: meet.EndDate, Valid: !meet.EndDate.IsZero()}
+}
+
+// Insert a meet, or update it if it was already stored under its ID. A meet without a date, such as one scraped by ID
+// before its page is read, keeps the dates it was stored with
 func InsertMeet(tx *sql.Tx, meet internal.Meet) error {
-	_, err := tx.Exec("INSERT INTO meet(id, name, date, season) VALUES($1, $2, $3, $4)", meet.ID, meet.Name, meet.Date, meet.Season)
+	_, err := tx.Exec(`INSERT INTO meet(id, name, date, season, end_date, venue, city, state, host, host_school_id, surface,
+        track_size, track_type, timing_company) VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+        ON CONFLICT (id) DO UPDATE SET name = COALESCE(NULLIF(EXCLUDED.name, ''), meet.name),
+        date = COALESCE(NULLIF(EXCLUDED.date, DATE '0001-01-01'), meet.date), season = EXCLUDED.season,
+        end_date = CASE WHEN EXCLUDED.date = DATE '0001-01-01' THEN meet.end_date ELSE EXCLUDED.end_date END,
+        venue = EXCLUDED.venue, city = EXCLUDED.city, state = EXCLUDED.state, host = EXCLUDED.host, host_school_id = EXCLUDED.host_school_id,
+        surface = EXCLUDED.surface, track_size = EXCLUDED.track_size, track_type = EXCLUDED.track_type, timing_company = EXCLUDED.timing_company`,
+		meet.ID,
+		meet.Name,
+		meet.Date,
+		meet.Season,
+		nullEndDate(meet),
+		nullString(meet.Venue),
+		nullString(meet.City),
+		nullString(meet.State),
+		nullString(meet.Host),
+		nullID(meet.HostSchoolID),
+		nullString(meet.Surface),
+		sql.NullInt32{Int32: int32(meet.TrackSize), Valid: meet.TrackSize > 0},
+		meet.TrackType,
+		nullString(meet.TimingCompany))
+	return err
+}
+
+// Update the details of a meet read from its page header, such as its venue, host and timing company
+func UpdateMeetDetails(tx *sql.Tx, meet internal.Meet) error {
+	_, err := tx.Exec(`UPDATE meet SET date = COALESCE(NULLIF($2::date, DATE '0001-01-01'), date),
+        end_date = CASE WHEN $2::date = DATE '0001-01-01' THEN end_date ELSE $3 END, venue = $4, city = $5, state = $6, host = $7,
+        host_school_id = $8, surface = $9, track_size = $10, track_type = $11, timing_company = $12,
+        name = COALESCE(NULLIF($13, ''), name) WHERE id = $1`,
+		meet.ID,
+		meet.Date,
+		nullEndDate(meet),
+		nullString(meet.Venue),
+		nullString(meet.City),
+		nullString(meet.State),
+		nullString(meet.Host),
+		nullID(meet.HostSchoolID),
+		nullString(meet.Surface),
+		sql.NullInt32{Int32: int32(meet.TrackSize), Valid: meet.TrackSize > 0},
+		meet.TrackType,
+		nullString(meet.TimingCompany),
+		meet.Name)
+	return err
+}
+
+// Every meet in the database, for backfills over historical meets
+func GetMeets(tx *sql.Tx) []internal.Meet {
+	rows, err := tx.Query(`SELECT id, name, season, date, COALESCE(end_date, date), COALESCE(venue, ''), COALESCE(city, ''),
+        COALESCE(state, ''), COALESCE(host, ''), COALESCE(host_school_id, 0), COALESCE(surface, ''), COALESCE(track_size, 0), track_type,
+        COALESCE(timing_company, '') FROM meet`)
+	if err != nil {
+		panic(err)
+	}
+
+	var meets []internal.Meet
+	for rows.Next() {
+		var meet internal.Meet
+		err = rows.Scan(&meet.ID, &meet.Name, &meet.Season, &meet.Date, &meet.EndDate, &meet.Venue, &meet.City, &meet.State,
+			&meet.Host, &meet.HostSchoolID, &meet.Surface, &meet.TrackSize, &meet.TrackType, &meet.TimingCompany)
+		if err != nil {
+			panic(err)
+		}
+		meets = append(meets, meet)
+	}
+	if err = rows.Close(); err != nil {
+		panic(err)
+	}
+	return meets
+}
+
+// The distinct event types contested at a meet
+func GetMeetEventTypes(tx *sql.Tx, meetID uint32) []internal.EventType {
+	rows, err := tx.Query("SELECT DISTINCT event_type FROM heat WHERE meet_id = $1", meetID)
+	if err != nil {
+		panic(err)
+	}
+
+	var events []internal.EventType
+	for rows.Next() {
+		var event internal.EventType
+		if err = rows.Scan(&event); err != nil {
+			panic(err)
+		}
+		events = append(events, event)
+	}
+	if err = rows.Close(); err != nil {
+		panic(err)
+	}
+	return events
+}
+
+func SetMeetSeason(tx *sql.Tx, meetID uint32, season int) error {
+	_, err := tx.Exec("UPDATE meet SET season = $1 WHERE id = $2", season, meetID)
 	return err
 }
 
+// Infer the season of every meet from its source, events and date, correcting meets stored under the wrong season.
+// Meets scraped from a cross country listing stay in cross country. Returns the number of meets corrected
+func BackfillSeasons(tx *sql.Tx) (int, error) {
+	rows, err := tx.Query(`SELECT meet_id FROM meet_map WHERE key LIKE 'tfrrs/xc/%' OR key LIKE 'athnet/xc/%'`)
+	if err != nil {
+		return 0, err
+	}
+	xcMeets := make(map[uint32]bool)
+	for rows.Next() {
+		var meetID uint32
+		if err := rows.Scan(&meetID); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		xcMeets[meetID] = true
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	corrected := 0
+	for _, meet := range GetMeets(tx) {
+		season := internal.XC
+		if !xcMeets[meet.ID] {
+			season = internal.InferSeason(GetMeetEventTypes(tx, meet.ID), meet.Date)
+		}
+		if season == meet.Season {
+			continue
+		}
+		if err := SetMeetSeason(tx, meet.ID, season); err != nil {
+			return corrected, err
+		}
+		corrected++
+	}
+	return corrected, nil
+}
+
 // For a list of school URLs, return a list of those for which there are no matches
 func GetMissingSchools(tx *sql.Tx, schoolURLs []string) []string {
 	missingSchools := make([]string, 0, len(schoolURLs))
@@ -235,6 +235,54 @@ func TestGetAthlete(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestAthleteProfile(t *testing.T) {
+	db := setupTestDB()
+	defer database.TeardownSchema(db)
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	school := internal.School{ID: 7, Name: "School", URL: "https://www.tfrrs.org/teams/tf/CA_college_f_School.html"}
+	if err = database.InsertSchool(tx, school); err != nil {
+		t.Fatal(err)
+	}
+	ath := internal.Athlete{
+		ID:          123,
+		Name:        "Jane Doe",
+		Schools:     []uint32{school.ID},
+		Year:        internal.JUNIOR,
+		Redshirt:    true,
+		Eligibility: 2,
+		Hometown:    "Portland, OR",
+		Rosters: []internal.Roster{
+			{AthleteID: 123, SchoolID: school.ID, Year: 2023, Season: internal.OUTDOOR, ClassYear: internal.JUNIOR},
+			{AthleteID: 123, SchoolID: school.ID, Year: 2022, Season: internal.OUTDOOR},
+		},
+	}
+	if err = database.InsertAthlete(tx, ath); err != nil {
+		t.Fatal(err)
+	}
+
+	res, found := database.GetAthlete(tx, ath.ID)
+	if !found {
+		t.Fatal("Could not find athlete in database")
+	}
+	if res.Year != ath.Year || !res.Redshirt || res.Eligibility != ath.Eligibility || res.Hometown != ath.Hometown {
+		t.Errorf("Expected profile %+v but got %+v", ath, res)
+	}
+	if len(res.Schools) != 1 || res.Schools[0] != school.ID {
+		t.Errorf("Expected the athlete to be on school %d but got %v", school.ID, res.Schools)
+	}
+	if len(res.Rosters) != 2 || res.Rosters[0].Year != 2022 || res.Rosters[1].ClassYear != internal.JUNIOR {
+		t.Errorf("Expected rosters ordered by season but got %+v", res.Rosters)
+	}
+
+	if err = tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+}
 func TestInsertHeat(t *testing.T) {
 	db := setupTestDB()
 	defer database.TeardownSchema(db)
@@ -282,7 +330,7 @@ func TestInsertHeat(t *testing.T) {
 		t.Error("Failed to insert preliminary meet", err)
 	}
 
-	_, err = database.InsertHeat(tx, internal.T5000M, meet.ID, heat)
+	_, err = database.InsertHeat(tx, internal.Heat{Type: internal.T5000M, MeetID: meet.ID, Sex: internal.MEN}, heat)
 	if err != nil {
 		t.Error("Insert heat operation failed:", err)
 	}
@@ -291,6 +339,68 @@ func TestInsertHeat(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestInsertFieldHeat(t *testing.T) {
+	db := setupTestDB()
+	defer database.TeardownSchema(db)
+	ath1 := internal.Athlete{
+		Name: "Ath1",
+		ID:   123,
+	}
+	ath2 := internal.Athlete{
+		Name: "Ath2",
+		ID:   456,
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = database.InsertAthlete(tx, ath1); err != nil {
+		t.Fatal("Failed to insert ath1", err)
+	}
+	if err = database.InsertAthlete(tx, ath2); err != nil {
+		t.Fatal("Failed to insert ath2", err)
+	}
+
+	heat := []internal.Result{
+		{
+			AthleteID: 123,
+			Quantity:  7.45,
+			Place:     1,
+		},
+		{
+			AthleteID: 456,
+			Status:    internal.FOUL,
+		},
+	}
+
+	meet := internal.Meet{
+		ID:   1234,
+		Name: "Bactic Championships",
+		Date: time.Date(2023, time.May, 6, 0, 0, 0, 0, time.UTC),
+	}
+	if err = database.InsertMeet(tx, meet); err != nil {
+		t.Fatal("Failed to insert preliminary meet", err)
+	}
+
+	heatID, err := database.InsertHeat(tx, internal.Heat{Type: internal.LONG_JUMP, MeetID: meet.ID}, heat)
+	if err != nil {
+		t.Fatal("Insert heat operation failed:", err)
+	}
+
+	var nulls int
+	if err = tx.QueryRow("SELECT COUNT(*) FROM result WHERE heat_id = $1 AND quant IS NULL", heatID).Scan(&nulls); err != nil {
+		t.Fatal(err)
+	}
+	if nulls != 1 {
+		t.Errorf("Expected the foul to be stored with a null mark, found %d null marks", nulls)
+	}
+
+	if err = tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestAthleteSchoolRelation(t *testing.T) {
 	db := setupTestDB()
 	defer database.TeardownSchema(db)
@@ -363,3 +473,501 @@ func TestInsertMeet(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestInsertTeamResults(t *testing.T) {
+	db := setupTestDB()
+	defer database.TeardownSchema(db)
+	school := internal.School{
+		ID:       uuid.New().ID(),
+		Name:     "School",
+		Division: internal.DIII,
+		URL:      "https://www.tfrrs.org/school_a",
+	}
+	meet := internal.Meet{
+		ID:     1234,
+		Name:   "Bactic XC Championships",
+		Season: internal.XC,
+		Date:   time.Date(2023, time.October, 28, 0, 0, 0, 0, time.UTC),
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = database.InsertSchool(tx, school); err != nil {
+		t.Fatal(err)
+	}
+	if err = database.InsertMeet(tx, meet); err != nil {
+		t.Fatal(err)
+	}
+	heatID, err := database.InsertHeat(tx, internal.Heat{Type: internal.XC_8K, MeetID: meet.ID}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	teams := []internal.TeamResult{{
+		SchoolID:   school.ID,
+		Place:      1,
+		Score:      38,
+		Scorers:    []int64{2, 5, 8, 10, 13},
+		Displacers: []int64{17},
+		AvgTime:    25*60 + 14.1,
+	}}
+	if err = database.InsertTeamResults(tx, heatID, teams); err != nil {
+		t.Fatal("Insert team results failed:", err)
+	}
+	if err = tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	standings := database.TeamStandings(db, heatID)
+	if len(standings) != 1 || standings[0].Score != 38 || len(standings[0].Scorers) != 5 {
+		t.Errorf("Unexpected team standings %+v", standings)
+	}
+}
+
+func TestUpdateMeetDetails(t *testing.T) {
+	db := setupTestDB()
+	defer database.TeardownSchema(db)
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	meet := internal.Meet{
+		ID:     1234,
+		Name:   "Bactic Championships",
+		Season: internal.INDOOR,
+		Date:   time.Date(2023, time.February, 10, 0, 0, 0, 0, time.UTC),
+	}
+	if err = database.InsertMeet(tx, meet); err != nil {
+		t.Fatal(err)
+	}
+
+	meet.EndDate = time.Date(2023, time.February, 11, 0, 0, 0, 0, time.UTC)
+	meet.Venue, meet.City, meet.State = "Reggie Lewis Center", "Boston", "MA"
+	meet.Host = "Tufts"
+	meet.TrackSize, meet.TrackType = 200, internal.BANKED_TRACK
+	meet.TimingCompany = "Bay State Timing"
+	if err = database.UpdateMeetDetails(tx, meet); err != nil {
+		t.Fatal(err)
+	}
+
+	meets := database.GetMeets(tx)
+	if len(meets) != 1 {
+		t.Fatalf("Expected 1 meet but got %d", len(meets))
+	}
+	got := meets[0]
+	if !got.EndDate.Equal(meet.EndDate) || got.Venue != meet.Venue || got.City != meet.City || got.State != meet.State ||
+		got.Host != meet.Host || got.TrackSize != meet.TrackSize || got.TrackType != meet.TrackType || got.TimingCompany != meet.TimingCompany {
+		t.Errorf("Expected meet details %+v but got %+v", meet, got)
+	}
+	if err = tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMeetRelation(t *testing.T) {
+	db := setupTestDB()
+	defer database.TeardownSchema(db)
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	meet := internal.Meet{
+		ID:   1234,
+		Name: "Bactic Championships",
+		Date: time.Date(2023, time.May, 6, 0, 0, 0, 0, time.UTC),
+	}
+	if err = database.InsertMeet(tx, meet); err != nil {
+		t.Fatal(err)
+	}
+	if _, found := database.GetMeetRelation(tx, "tfrrs/tf/79700"); found {
+		t.Error("Found a meet relation before it was added")
+	}
+	if err = database.AddMeetRelation(tx, "tfrrs/tf/79700", meet.ID); err != nil {
+		t.Fatal(err)
+	}
+	if id, found := database.GetMeetRelation(tx, "tfrrs/tf/79700"); !found || id != meet.ID {
+		t.Errorf("Expected meet %d but got %d", meet.ID, id)
+	}
+	if _, found := database.GetMeetRelation(tx, "tfrrs/xc/79700"); found {
+		t.Error("Cross country and track meet keys should not collide")
+	}
+	if err = tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBackfillSeasons(t *testing.T) {
+	db := setupTestDB()
+	defer database.TeardownSchema(db)
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the track meets were stored under the zero value season, XC, and the cross country meet under indoor
+	meets := []internal.Meet{
+		{ID: 1, Name: "Indoor Invitational", Date: time.Date(2023, time.February, 10, 0, 0, 0, 0, time.UTC)},
+		{ID: 2, Name: "Outdoor Invitational", Date: time.Date(2023, time.April, 15, 0, 0, 0, 0, time.UTC)},
+		{ID: 3, Name: "Early Season Opener", Date: time.Date(2023, time.August, 31, 0, 0, 0, 0, time.UTC), Season: internal.INDOOR},
+	}
+	for _, meet := range meets {
+		if err = database.InsertMeet(tx, meet); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the cross country meet's races were not parsed, so only its listing places it in cross country
+	if err = database.AddMeetRelation(tx, "tfrrs/xc/3", 3); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = database.InsertHeat(tx, internal.Heat{Type: internal.T60M, MeetID: 1}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = database.InsertHeat(tx, internal.Heat{Type: internal.T400M, MeetID: 2}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	corrected, err := database.BackfillSeasons(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if corrected != 3 {
+		t.Errorf("Expected 3 meets to be corrected but got %d", corrected)
+	}
+	seasons := map[uint32]int{1: internal.INDOOR, 2: internal.OUTDOOR, 3: internal.XC}
+	for _, meet := range database.GetMeets(tx) {
+		if meet.Season != seasons[meet.ID] {
+			t.Errorf("Expected meet %d to be in season %d but got %d", meet.ID, seasons[meet.ID], meet.Season)
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBackfillProgress(t *testing.T) {
+	db := setupTestDB()
+	defer database.TeardownSchema(db)
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if database.GetBackfillProgress(tx, "tfrrs/listing/xc/2023-10") {
+		t.Error("Found backfill progress before it was set")
+	}
+	if err = database.SetBackfillProgress(tx, "tfrrs/listing/xc/2023-10"); err != nil {
+		t.Fatal(err)
+	}
+	// marking a key twice should not fail on resume
+	if err = database.SetBackfillProgress(tx, "tfrrs/listing/xc/2023-10"); err != nil {
+		t.Fatal(err)
+	}
+	if !database.GetBackfillProgress(tx, "tfrrs/listing/xc/2023-10") {
+		t.Error("Expected backfill progress to be recorded")
+	}
+	if database.GetBackfillProgress(tx, "tfrrs/listing/track/2023-10") {
+		t.Error("Backfill progress of different sports should not collide")
+	}
+	if err = tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRescrapeMeet(t *testing.T) {
+	db := setupTestDB()
+	defer database.TeardownSchema(db)
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = database.InsertAthlete(tx, internal.Athlete{ID: 1, Name: "Ath"}); err != nil {
+		t.Fatal(err)
+	}
+	meet := internal.Meet{
+		ID:   1234,
+		Name: "Bactic Championships",
+		Date: time.Date(2023, time.May, 6, 0, 0, 0, 0, time.UTC),
+	}
+	heat := internal.Heat{Type: internal.T1500M, MeetID: meet.ID, Stage: internal.FINAL}
+
+	// the second scrape corrects the mark posted by the first
+	var heatIDs []uint32
+	for _, quantity := range []float32{250, 245.5} {
+		if err = database.InsertMeet(tx, meet); err != nil {
+			t.Fatal(err)
+		}
+		heatID, err := database.InsertHeat(tx, heat, []internal.Result{{AthleteID: 1, Quantity: quantity, Place: 1}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		heatIDs = append(heatIDs, heatID)
+	}
+	if heatIDs[0] != heatIDs[1] {
+		t.Errorf("Expected the heat to be updated in place but got heats %v", heatIDs)
+	}
+
+	var meets, heats, results int
+	var quantity float32
+	if err = tx.QueryRow("SELECT (SELECT COUNT(*) FROM meet), (SELECT COUNT(*) FROM heat), (SELECT COUNT(*) FROM result)").Scan(&meets, &heats, &results); err != nil {
+		t.Fatal(err)
+	}
+	if meets != 1 || heats != 1 || results != 1 {
+		t.Errorf("Expected a single meet, heat and result but got %d, %d and %d", meets, heats, results)
+	}
+	if err = tx.QueryRow("SELECT quant FROM result WHERE heat_id = $1", heatIDs[0]).Scan(&quantity); err != nil {
+		t.Fatal(err)
+	}
+	if quantity != 245.5 {
+		t.Errorf("Expected the result to be updated to 245.5 but got %v", quantity)
+	}
+	if err = tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestResultCorrections(t *testing.T) {
+	db := setupTestDB()
+	defer database.TeardownSchema(db)
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []uint32{1, 2, 3, 4} {
+		if err = database.InsertAthlete(tx, internal.Athlete{ID: id, Name: "Ath"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	meet := internal.Meet{
+		ID:   1234,
+		Name: "Bactic Championships",
+		Date: time.Date(2023, time.May, 6, 0, 0, 0, 0, time.UTC),
+	}
+	if err = database.InsertMeet(tx, meet); err != nil {
+		t.Fatal(err)
+	}
+	heat := internal.Heat{Type: internal.T800M, MeetID: meet.ID, Stage: internal.FINAL}
+	heatID, err := database.InsertHeat(tx, heat, []internal.Result{
+		{AthleteID: 1, Quantity: 115.2, Place: 1},
+		{AthleteID: 2, Quantity: 116.4, Place: 2},
+		{AthleteID: 4, Quantity: 118.0, Place: 3},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var winnerID, relinkedID, scratchedID uint32
+	if err = tx.QueryRow("SELECT id FROM result WHERE heat_id = $1 AND ath_id = 1", heatID).Scan(&winnerID); err != nil {
+		t.Fatal(err)
+	}
+	if err = tx.QueryRow("SELECT id FROM result WHERE heat_id = $1 AND ath_id = 2", heatID).Scan(&relinkedID); err != nil {
+		t.Fatal(err)
+	}
+	if err = tx.QueryRow("SELECT id FROM result WHERE heat_id = $1 AND ath_id = 4", heatID).Scan(&scratchedID); err != nil {
+		t.Fatal(err)
+	}
+	if err = tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the winner is disqualified, the runner up's result is re-linked to another athlete, and the third place result is
+	// no longer listed
+	tx, err = db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = database.InsertHeat(tx, heat, []internal.Result{
+		{AthleteID: 1, Status: internal.DQ, DQCode: "163-3a"},
+		{AthleteID: 3, Quantity: 116.4, Place: 1},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	removed, err := database.RemoveStaleResults(tx, meet.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed != 1 {
+		t.Errorf("Expected the unlisted result to be removed but removed %d", removed)
+	}
+
+	changes := database.GetResultChanges(tx, winnerID)
+	expected := map[string][2]string{
+		"pl":      {"1", "0"},
+		"quant":   {"115.2", ""},
+		"status":  {"0", "7"},
+		"dq_code": {"", "163-3a"},
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf("Expected %d changes to the winner's result but got %+v", len(expected), changes)
+	}
+	for _, change := range changes {
+		if change.Version != 2 {
+			t.Errorf("Expected the correction to be version 2 but got %d", change.Version)
+		}
+		if values := expected[change.Field]; change.Old != values[0] || change.New != values[1] {
+			t.Errorf("Expected %s to change from %q to %q but got %+v", change.Field, values[0], values[1], change)
+		}
+	}
+
+	// the re-linked result keeps its row, and logs its new athlete
+	var athleteID uint32
+	if err = tx.QueryRow("SELECT ath_id FROM result WHERE id = $1", relinkedID).Scan(&athleteID); err != nil {
+		t.Fatal(err)
+	}
+	if athleteID != 3 {
+		t.Errorf("Expected the re-linked result to belong to athlete 3 but got %d", athleteID)
+	}
+	changes = database.GetResultChanges(tx, relinkedID)
+	expected = map[string][2]string{
+		"ath_id": {"2", "3"},
+		"pl":     {"2", "1"},
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf("Expected %d changes to the re-linked result but got %+v", len(expected), changes)
+	}
+	for _, change := range changes {
+		if values := expected[change.Field]; change.Version != 2 || change.Old != values[0] || change.New != values[1] {
+			t.Errorf("Expected %s to change from %q to %q in version 2 but got %+v", change.Field, values[0], values[1], change)
+		}
+	}
+
+	changes = database.GetResultChanges(tx, scratchedID)
+	if len(changes) == 0 || changes[0].Field != "ath_id" || changes[0].Old != "4" || changes[0].New != "" {
+		t.Errorf("Expected the removal of the unlisted result to be logged but got %+v", changes)
+	}
+	if err = tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Test that a correction replaces a result's attempts and relay legs, and logs them as changed
+func TestResultDetailCorrections(t *testing.T) {
+	db := setupTestDB()
+	defer database.TeardownSchema(db)
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []uint32{1, 2, 3, 4, 5} {
+		if err = database.InsertAthlete(tx, internal.Athlete{ID: id, Name: "Ath"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	school := internal.School{ID: 5, Name: "School", Division: internal.DIII, URL: "https://www.tfrrs.org/school_a"}
+	if err = database.InsertSchool(tx, school); err != nil {
+		t.Fatal(err)
+	}
+	meet := internal.Meet{
+		ID:   1234,
+		Name: "Bactic Championships",
+		Date: time.Date(2023, time.May, 6, 0, 0, 0, 0, time.UTC),
+	}
+	if err = database.InsertMeet(tx, meet); err != nil {
+		t.Fatal(err)
+	}
+	jump := internal.Heat{Type: internal.LONG_JUMP, MeetID: meet.ID, Stage: internal.FINAL}
+	jumpID, err := database.InsertHeat(tx, jump, []internal.Result{{
+		AthleteID: 1,
+		Quantity:  7.45,
+		Place:     1,
+		Attempts: []internal.Attempt{
+			{Number: 1, Quantity: 7.12},
+			{Number: 2, Status: internal.FOUL},
+			{Number: 3, Quantity: 7.45},
+		},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	relay := internal.Heat{Type: internal.T4X400, MeetID: meet.ID, Stage: internal.FINAL}
+	relayID, err := database.InsertHeat(tx, relay, []internal.Result{{
+		Place:    1,
+		Quantity: 193.76,
+		SchoolID: school.ID,
+		Team:     "A",
+		Members:  []uint32{1, 2, 3, 4},
+		Splits:   []float32{49.1, 48.21, 48.9, 47.55},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var jumpResultID, relayResultID uint32
+	if err = tx.QueryRow("SELECT id FROM result WHERE heat_id = $1", jumpID).Scan(&jumpResultID); err != nil {
+		t.Fatal(err)
+	}
+	if err = tx.QueryRow("SELECT id FROM result WHERE heat_id = $1", relayID).Scan(&relayResultID); err != nil {
+		t.Fatal(err)
+	}
+	if err = tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the third attempt is struck and the second is found to be valid, and the anchor leg is re-linked to another athlete
+	tx, err = db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = database.InsertHeat(tx, jump, []internal.Result{{
+		AthleteID: 1,
+		Quantity:  7.3,
+		Place:     1,
+		Attempts: []internal.Attempt{
+			{Number: 1, Quantity: 7.12},
+			{Number: 2, Quantity: 7.3},
+		},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = database.InsertHeat(tx, relay, []internal.Result{{
+		Place:    1,
+		Quantity: 193.76,
+		SchoolID: school.ID,
+		Team:     "A",
+		Members:  []uint32{1, 2, 3, 5},
+		Splits:   []float32{49.1, 48.21, 48.9, 47.55},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+
+	var attempts int
+	if err = tx.QueryRow("SELECT COUNT(*) FROM attempt WHERE result_id = $1", jumpResultID).Scan(&attempts); err != nil {
+		t.Fatal(err)
+	}
+	if attempts != 2 {
+		t.Errorf("Expected the struck attempt to be removed but found %d attempts", attempts)
+	}
+	changes := database.GetResultChanges(tx, jumpResultID)
+	expected := map[string][2]string{
+		"quant":    {"7.45", "7.3"},
+		"attempts": {"1:7.12::0 2:::1 3:7.45::0", "1:7.12::0 2:7.3::0"},
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf("Expected %d changes to the jump but got %+v", len(expected), changes)
+	}
+	for _, change := range changes {
+		if values := expected[change.Field]; change.Version != 2 || change.Old != values[0] || change.New != values[1] {
+			t.Errorf("Expected %s to change from %q to %q in version 2 but got %+v", change.Field, values[0], values[1], change)
+		}
+	}
+
+	var anchor uint32
+	if err = tx.QueryRow("SELECT ath_id FROM relay_leg WHERE result_id = $1 AND leg = 4", relayResultID).Scan(&anchor); err != nil {
+		t.Fatal(err)
+	}
+	if anchor != 5 {
+		t.Errorf("Expected the anchor leg to be re-linked to athlete 5 but got %d", anchor)
+	}
+	changes = database.GetResultChanges(tx, relayResultID)
+	if len(changes) != 1 || changes[0].Field != "legs" || changes[0].Version != 2 ||
+		changes[0].Old != "1:1:49.1 2:2:48.21 3:3:48.9 4:4:47.55" || changes[0].New != "1:1:49.1 2:2:48.21 3:3:48.9 4:5:47.55" {
+		t.Errorf("Expected the re-linked leg to be logged but got %+v", changes)
+	}
+	if err = tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+}
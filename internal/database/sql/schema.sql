@@ -1,23 +1,76 @@
 CREATE TABLE IF NOT EXISTS athlete(
     id BIGINT PRIMARY KEY,
     name VARCHAR,
-    year INT
+    year INT,
+    redshirt BOOLEAN NOT NULL DEFAULT FALSE,
+    eligibility SMALLINT,
+    hometown VARCHAR
+);
+
+-- databases made before a column was added to its table get it here
+ALTER TABLE athlete ADD COLUMN IF NOT EXISTS redshirt BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE athlete ADD COLUMN IF NOT EXISTS eligibility SMALLINT;
+ALTER TABLE athlete ADD COLUMN IF NOT EXISTS hometown VARCHAR;
+
+CREATE TABLE IF NOT EXISTS school(
+    id BIGINT PRIMARY KEY,
+    name VARCHAR NOT NULL,
+    division SMALLINT NOT NULL,
+    url VARCHAR NOT NULL UNIQUE
 );
 
 CREATE TABLE IF NOT EXISTS meet(
     id BIGINT PRIMARY KEY,
     name VARCHAR NOT NULL,
     season SMALLINT NOT NULL,
-    date DATE NOT NULL
+    date DATE NOT NULL,
+    end_date DATE,
+    venue VARCHAR,
+    city VARCHAR,
+    state VARCHAR,
+    host VARCHAR,
+    host_school_id BIGINT,
+    surface VARCHAR,
+    track_size SMALLINT,
+    track_type SMALLINT NOT NULL DEFAULT 0,
+    timing_company VARCHAR,
+    FOREIGN KEY(host_school_id) REFERENCES school(id)
 );
 
+ALTER TABLE meet ADD COLUMN IF NOT EXISTS end_date DATE;
+ALTER TABLE meet ADD COLUMN IF NOT EXISTS venue VARCHAR;
+ALTER TABLE meet ADD COLUMN IF NOT EXISTS city VARCHAR;
+ALTER TABLE meet ADD COLUMN IF NOT EXISTS state VARCHAR;
+ALTER TABLE meet ADD COLUMN IF NOT EXISTS host VARCHAR;
+ALTER TABLE meet ADD COLUMN IF NOT EXISTS host_school_id BIGINT REFERENCES school(id);
+ALTER TABLE meet ADD COLUMN IF NOT EXISTS surface VARCHAR;
+ALTER TABLE meet ADD COLUMN IF NOT EXISTS track_size SMALLINT;
+ALTER TABLE meet ADD COLUMN IF NOT EXISTS track_type SMALLINT NOT NULL DEFAULT 0;
+ALTER TABLE meet ADD COLUMN IF NOT EXISTS timing_company VARCHAR;
+
 CREATE TABLE IF NOT EXISTS heat(
     id BIGINT PRIMARY KEY,
     meet_id BIGINT,
     event_type SMALLINT NOT NULL,
+    sex SMALLINT NOT NULL DEFAULT 0,
+    stage SMALLINT NOT NULL DEFAULT 1,
+    heat_num SMALLINT NOT NULL DEFAULT 0,
+    section SMALLINT NOT NULL DEFAULT 0,
+    wind_ms FLOAT,
+    date DATE,
     FOREIGN KEY(meet_id) REFERENCES meet(id)
 );
 
+ALTER TABLE heat ADD COLUMN IF NOT EXISTS sex SMALLINT NOT NULL DEFAULT 0;
+ALTER TABLE heat ADD COLUMN IF NOT EXISTS stage SMALLINT NOT NULL DEFAULT 1;
+ALTER TABLE heat ADD COLUMN IF NOT EXISTS heat_num SMALLINT NOT NULL DEFAULT 0;
+ALTER TABLE heat ADD COLUMN IF NOT EXISTS section SMALLINT NOT NULL DEFAULT 0;
+ALTER TABLE heat ADD COLUMN IF NOT EXISTS wind_ms FLOAT;
+ALTER TABLE heat ADD COLUMN IF NOT EXISTS date DATE;
+
+-- a meet has one heat of each event, sex, stage, heat and section
+CREATE UNIQUE INDEX IF NOT EXISTS heat_entry ON heat(meet_id, event_type, sex, stage, heat_num, section);
+
 CREATE TABLE IF NOT EXISTS result(
     id BIGINT PRIMARY KEY,
     heat_id BIGINT,
@@ -25,16 +78,96 @@ CREATE TABLE IF NOT EXISTS result(
     pl SMALLINT,
     quant FLOAT,
     wind_ms FLOAT,
+    aided BOOLEAN NOT NULL DEFAULT FALSE,
     stage SMALLINT,
+    status SMALLINT NOT NULL DEFAULT 0,
+    dq_code VARCHAR,
+    timing SMALLINT NOT NULL DEFAULT 0,
+    school_id BIGINT,
+    team VARCHAR,
+    version INT NOT NULL DEFAULT 1,
+    scraped TIMESTAMP NOT NULL DEFAULT NOW(),
     FOREIGN KEY(heat_id) REFERENCES heat(id),
-    FOREIGN KEY(ath_id) REFERENCES athlete(id)
+    FOREIGN KEY(ath_id) REFERENCES athlete(id),
+    FOREIGN KEY(school_id) REFERENCES school(id)
 );
 
-CREATE TABLE IF NOT EXISTS school(
+ALTER TABLE result ADD COLUMN IF NOT EXISTS aided BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE result ADD COLUMN IF NOT EXISTS status SMALLINT NOT NULL DEFAULT 0;
+ALTER TABLE result ADD COLUMN IF NOT EXISTS dq_code VARCHAR;
+ALTER TABLE result ADD COLUMN IF NOT EXISTS timing SMALLINT NOT NULL DEFAULT 0;
+ALTER TABLE result ADD COLUMN IF NOT EXISTS school_id BIGINT REFERENCES school(id);
+ALTER TABLE result ADD COLUMN IF NOT EXISTS team VARCHAR;
+ALTER TABLE result ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1;
+ALTER TABLE result ADD COLUMN IF NOT EXISTS scraped TIMESTAMP NOT NULL DEFAULT NOW();
+
+-- an athlete, or a school's relay team, has one result per heat
+CREATE UNIQUE INDEX IF NOT EXISTS result_entry ON result(heat_id, COALESCE(ath_id, 0), COALESCE(school_id, 0), COALESCE(team, ''));
+
+-- results are kept out of the foreign keys so that the history of removed results remains
+CREATE TABLE IF NOT EXISTS result_change(
+    result_id BIGINT NOT NULL,
+    version INT NOT NULL,
+    field VARCHAR NOT NULL,
+    old_value VARCHAR,
+    new_value VARCHAR,
+    changed TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY(result_id, version, field)
+);
+
+CREATE TABLE IF NOT EXISTS relay_leg(
+    result_id BIGINT NOT NULL,
+    leg SMALLINT NOT NULL,
+    ath_id BIGINT,
+    split FLOAT,
+    FOREIGN KEY(result_id) REFERENCES result(id),
+    FOREIGN KEY(ath_id) REFERENCES athlete(id),
+    PRIMARY KEY(result_id, leg)
+);
+
+CREATE TABLE IF NOT EXISTS attempt(
+    result_id BIGINT NOT NULL,
+    num SMALLINT NOT NULL,
+    quant FLOAT,
+    wind_ms FLOAT,
+    status SMALLINT NOT NULL DEFAULT 0,
+    FOREIGN KEY(result_id) REFERENCES result(id),
+    PRIMARY KEY(result_id, num)
+);
+
+CREATE TABLE IF NOT EXISTS height_attempt(
+    result_id BIGINT NOT NULL,
+    height FLOAT NOT NULL,
+    attempts VARCHAR NOT NULL,
+    FOREIGN KEY(result_id) REFERENCES result(id),
+    PRIMARY KEY(result_id, height)
+);
+
+CREATE TABLE IF NOT EXISTS combined_component(
+    result_id BIGINT NOT NULL,
+    event_type SMALLINT NOT NULL,
+    quant FLOAT,
+    points INT NOT NULL,
+    computed_points INT NOT NULL,
+    status SMALLINT NOT NULL DEFAULT 0,
+    FOREIGN KEY(result_id) REFERENCES result(id),
+    PRIMARY KEY(result_id, event_type)
+);
+
+CREATE TABLE IF NOT EXISTS team_result(
     id BIGINT PRIMARY KEY,
-    name VARCHAR NOT NULL,
-    division SMALLINT NOT NULL,
-    url VARCHAR NOT NULL UNIQUE
+    heat_id BIGINT NOT NULL,
+    school_id BIGINT NOT NULL,
+    pl SMALLINT,
+    score INT,
+    scorers SMALLINT[],
+    displacers SMALLINT[],
+    total_time FLOAT,
+    avg_time FLOAT,
+    spread FLOAT,
+    FOREIGN KEY(heat_id) REFERENCES heat(id),
+    FOREIGN KEY(school_id) REFERENCES school(id),
+    UNIQUE(heat_id, school_id)
 );
 
 CREATE TABLE IF NOT EXISTS league(
@@ -51,7 +184,35 @@ CREATE TABLE IF NOT EXISTS athlete_in_school(
     PRIMARY KEY(athlete_id, school_id)
 );
 
+CREATE TABLE IF NOT EXISTS roster(
+    athlete_id BIGINT NOT NULL,
+    school_id BIGINT NOT NULL,
+    year SMALLINT NOT NULL,
+    season SMALLINT NOT NULL,
+    class_year SMALLINT,
+    FOREIGN KEY(athlete_id) REFERENCES athlete(id),
+    FOREIGN KEY(school_id) REFERENCES school(id),
+    PRIMARY KEY(athlete_id, school_id, year, season)
+);
+
 CREATE TABLE IF NOT EXISTS athlete_map(
     x BIGINT PRIMARY KEY,
     y BIGINT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS backfill_progress(
+    key VARCHAR PRIMARY KEY,
+    completed TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS meet_map(
+    key VARCHAR PRIMARY KEY,
+    meet_id BIGINT NOT NULL,
+    FOREIGN KEY(meet_id) REFERENCES meet(id)
+);
+
+CREATE TABLE IF NOT EXISTS athlete_source_map(
+    key VARCHAR PRIMARY KEY,
+    athlete_id BIGINT NOT NULL,
+    FOREIGN KEY(athlete_id) REFERENCES athlete(id)
 );
\ No newline at end of file
@@ -1,8 +1,18 @@
 DROP TABLE IF EXISTS league;
+DROP TABLE IF EXISTS attempt;
+DROP TABLE IF EXISTS height_attempt;
+DROP TABLE IF EXISTS combined_component;
+DROP TABLE IF EXISTS relay_leg;
+DROP TABLE IF EXISTS team_result;
+DROP TABLE IF EXISTS result_change;
 DROP TABLE IF EXISTS result;
 DROP TABLE IF EXISTS heat;
+DROP TABLE IF EXISTS athlete_source_map;
 DROP TABLE IF EXISTS athlete_in_school;
+DROP TABLE IF EXISTS roster;
 DROP TABLE IF EXISTS athlete;
-DROP TABLE IF EXISTS school;
+DROP TABLE IF EXISTS meet_map;
+DROP TABLE IF EXISTS backfill_progress;
 DROP TABLE IF EXISTS meet;
+DROP TABLE IF EXISTS school;
 DROP TABLE IF EXISTS athlete_map;
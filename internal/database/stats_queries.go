@@ -2,11 +2,70 @@ package database
 
 import (
 	"bactic/internal"
+	"bactic/internal/stats"
 	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
 )
 
+// Restricts the results a stats query considers. Zero-valued fields do not filter
+type ResultFilter struct {
+	Sex int
+	// Only consider results from finals, leaving out prelim and semifinal rounds
+	FinalsOnly bool
+	// Leave out marks set with a tailwind over the limit, and marks in wind events with no wind reading
+	WindLegal bool
+	// Leave out hand-timed marks
+	RequireFAT bool
+	// Only consider marks from meets on this indoor track type
+	TrackType int
+}
+
+// Build the filter's conditions on the query's result r and heat h, to be appended to its WHERE clause.
+// Placeholders are numbered after the query's existing arguments, which are returned with the filter's appended
+func (f ResultFilter) where(args []interface{}) (string, []interface{}) {
+	var conds []string
+	if f.Sex != internal.UNKNOWN_SEX {
+		args = append(args, f.Sex)
+		conds = append(conds, fmt.Sprintf("h.sex = $%d", len(args)))
+	}
+	if f.FinalsOnly {
+		args = append(args, internal.FINAL)
+		conds = append(conds, fmt.Sprintf("h.stage = $%d", len(args)))
+	}
+	if f.WindLegal {
+		var windEvents []int64
+		for _, e := range internal.WindEvents() {
+			windEvents = append(windEvents, int64(e))
+		}
+		args = append(args, pq.Array(windEvents))
+		conds = append(conds, fmt.Sprintf("NOT r.aided AND (r.wind_ms IS NOT NULL OR h.event_type <> ALL($%d))", len(args)))
+	}
+	if f.TrackType != internal.UNKNOWN_TRACK {
+		args = append(args, f.TrackType)
+		conds = append(conds, fmt.Sprintf("h.meet_id IN (SELECT id FROM meet WHERE track_type = $%d)", len(args)))
+	}
+	if f.RequireFAT {
+		args = append(args, internal.FAT)
+		conds = append(conds, fmt.Sprintf("r.timing = $%d", len(args)))
+	}
+
+	if len(conds) == 0 {
+		return "", args
+	}
+	return " AND " + strings.Join(conds, " AND "), args
+}
+
+// The FAT equivalent of the query's result r in an event, so hand and FAT times are compared on the same footing
+func fatQuant(eventType internal.EventType) string {
+	return fmt.Sprintf("(r.quant + CASE WHEN r.timing = %d THEN %f ELSE 0 END)", internal.HAND, eventType.HandTimingOffset())
+}
+
 // Return a bucketing of data from table into nBuckets
-func Histogram(db *sql.DB, eventType internal.EventType, nBuckets int) []int {
+func Histogram(db *sql.DB, eventType internal.EventType, nBuckets int, filter ResultFilter) []int {
 	if nBuckets <= 0 {
 		panic("nBuckets must be greater than zero")
 	}
@@ -15,26 +74,29 @@ func Histogram(db *sql.DB, eventType internal.EventType, nBuckets int) []int {
 		low  float32
 		high float32
 	)
-	row := db.QueryRow("SELECT MAX(r.quant) FROM result r LEFT JOIN heat h ON r.heat_id = h.id WHERE h.event_type=?", eventType)
+	cond, args := filter.where([]interface{}{eventType})
+	row := db.QueryRow("SELECT MAX(r.quant) FROM result r JOIN heat h ON r.heat_id = h.id WHERE h.event_type = $1"+cond, args...)
 	err := row.Scan(&high)
 	if err != nil {
 		panic(err)
 	}
 
-	row = db.QueryRow("SELECT MIN(r.quant) FROM result r LEFT JOIN heat h ON r.heat_id = h.id WHERE h.event_type=?", eventType)
+	row = db.QueryRow("SELECT MIN(r.quant) FROM result r JOIN heat h ON r.heat_id = h.id WHERE h.event_type = $1"+cond, args...)
 	err = row.Scan(&low)
 	if err != nil {
 		panic(err)
 	}
 
+	bucket := fmt.Sprintf(" AND r.quant >= $%d AND r.quant < $%d", len(args)+1, len(args)+2)
+	lastBucket := fmt.Sprintf(" AND r.quant >= $%d AND r.quant <= $%d", len(args)+1, len(args)+2)
 	inc := (high - low) / float32(nBuckets)
 	for i := 0; i < nBuckets-1; i++ {
-		row = db.QueryRow("SELECT COUNT(r.id) FROM result r LEFT JOIN heat h ON r.heat_id = h.id WHERE h.EVENT_TYPE=? AND r.quant >= ? AND r.quant < ?", eventType, inc*float32(i), inc*float32(i+1))
+		row = db.QueryRow("SELECT COUNT(r.id) FROM result r JOIN heat h ON r.heat_id = h.id WHERE h.event_type = $1"+cond+bucket, append(args, low+inc*float32(i), low+inc*float32(i+1))...)
 		if err = row.Scan(&hist[i]); err != nil {
 			panic(err)
 		}
 	}
-	row = db.QueryRow("SELECT COUNT(r.id) FROM result r LEFT JOIN heat h ON r.heat_id = h.id WHERE h.EVENT_TYPE=? AND r.quant >= ? AND r.quant <= ?", eventType, inc*float32(nBuckets-1), inc*float32(nBuckets))
+	row = db.QueryRow("SELECT COUNT(r.id) FROM result r JOIN heat h ON r.heat_id = h.id WHERE h.event_type = $1"+cond+lastBucket, append(args, low+inc*float32(nBuckets-1), high)...)
 	if err = row.Scan(&hist[nBuckets-1]); err != nil {
 		panic(err)
 	}
@@ -42,10 +104,302 @@ func Histogram(db *sql.DB, eventType internal.EventType, nBuckets int) []int {
 	return hist
 }
 
-func PersonalRecord(db *sql.DB, eventType internal.EventType, athID uint32) float32 {
-	panic("Not implemented!")
+// Fraction of an athlete's attempts in a field event that were fouls. Passes are not counted as attempts
+func FoulRate(db *sql.DB, eventType internal.EventType, athID uint32, filter ResultFilter) float32 {
+	var fouls, attempts int
+	cond, args := filter.where([]interface{}{internal.FOUL, internal.PASS, athID, eventType})
+	row := db.QueryRow(`SELECT COUNT(a.num) FILTER (WHERE a.status = $1), COUNT(a.num) FILTER (WHERE a.status <> $2)
+        FROM attempt a JOIN result r ON a.result_id = r.id JOIN heat h ON r.heat_id = h.id
+        WHERE r.ath_id = $3 AND h.event_type = $4`+cond, args...)
+	if err := row.Scan(&fouls, &attempts); err != nil {
+		panic(err)
+	}
+	if attempts == 0 {
+		return 0
+	}
+	return float32(fouls) / float32(attempts)
+}
+
+// Standard deviation of the valid marks in a result's series
+func SeriesConsistency(db *sql.DB, resultID uint32) float32 {
+	var stddev float32
+	row := db.QueryRow("SELECT COALESCE(STDDEV_POP(quant), 0) FROM attempt WHERE result_id = $1 AND status = $2", resultID, internal.VALID)
+	if err := row.Scan(&stddev); err != nil {
+		panic(err)
+	}
+	return stddev
+}
+
+// The attempt number in which a result's best mark was achieved, or zero if the series has no valid marks
+func BestMarkRound(db *sql.DB, resultID uint32) int {
+	var round int
+	row := db.QueryRow("SELECT num FROM attempt WHERE result_id = $1 AND status = $2 ORDER BY quant DESC, num ASC LIMIT 1", resultID, internal.VALID)
+	err := row.Scan(&round)
+	if err == sql.ErrNoRows {
+		return 0
+	} else if err != nil {
+		panic(err)
+	}
+	return round
+}
+
+// Fraction of an athlete's attempts in a vertical jump that cleared the bar. Passes are not counted as attempts
+func ClearanceEfficiency(db *sql.DB, eventType internal.EventType, athID uint32, filter ResultFilter) float32 {
+	cond, args := filter.where([]interface{}{athID, eventType})
+	rows, err := db.Query(`SELECT a.result_id, a.height, a.attempts
+        FROM height_attempt a JOIN result r ON a.result_id = r.id JOIN heat h ON r.heat_id = h.id
+        WHERE r.ath_id = $1 AND h.event_type = $2`+cond+`
+        ORDER BY a.result_id, a.height`, args...)
+	if err != nil {
+		panic(err)
+	}
+
+	var heights []internal.HeightAttempt
+	for rows.Next() {
+		var height internal.HeightAttempt
+		if err = rows.Scan(&height.ResultID, &height.Height, &height.Attempts); err != nil {
+			panic(err)
+		}
+		heights = append(heights, height)
+	}
+	if err = rows.Close(); err != nil {
+		panic(err)
+	}
+	return stats.ClearanceEfficiency(heights)
+}
+
+// Combined event results whose reported points disagree with the scoring tables, either in a discipline or in the total
+func CombinedDiscrepancies(db *sql.DB, filter ResultFilter) []uint32 {
+	cond, args := filter.where(nil)
+	rows, err := db.Query(`SELECT r.id FROM result r JOIN combined_component c ON c.result_id = r.id JOIN heat h ON r.heat_id = h.id
+        WHERE TRUE`+cond+`
+        GROUP BY r.id, r.quant
+        HAVING BOOL_OR(c.points <> c.computed_points) OR SUM(c.points) <> r.quant`, args...)
+	if err != nil {
+		panic(err)
+	}
+
+	var ids []uint32
+	var id uint32
+	for rows.Next() {
+		if err = rows.Scan(&id); err != nil {
+			panic(err)
+		}
+		ids = append(ids, id)
+	}
+	if err = rows.Close(); err != nil {
+		panic(err)
+	}
+	return ids
+}
+
+// Team standings of a scored race ordered by place
+func TeamStandings(db *sql.DB, heatID uint32) []internal.TeamResult {
+	rows, err := db.Query(`SELECT id, heat_id, school_id, COALESCE(pl, 0), COALESCE(score, 0), scorers, displacers,
+        COALESCE(total_time, 0), COALESCE(avg_time, 0), COALESCE(spread, 0)
+        FROM team_result WHERE heat_id = $1 ORDER BY pl`, heatID)
+	if err != nil {
+		panic(err)
+	}
+
+	var standings []internal.TeamResult
+	for rows.Next() {
+		var result internal.TeamResult
+		err = rows.Scan(&result.ID, &result.HeatID, &result.SchoolID, &result.Place, &result.Score, pq.Array(&result.Scorers),
+			pq.Array(&result.Displacers), &result.TotalTime, &result.AvgTime, &result.Spread)
+		if err != nil {
+			panic(err)
+		}
+		standings = append(standings, result)
+	}
+	if err = rows.Close(); err != nil {
+		panic(err)
+	}
+	return standings
+}
+
+// Number of results in an event with each status, such as DNF or DQ
+func StatusCounts(db *sql.DB, eventType internal.EventType, filter ResultFilter) map[int]int {
+	cond, args := filter.where([]interface{}{eventType})
+	rows, err := db.Query("SELECT r.status, COUNT(r.id) FROM result r JOIN heat h ON r.heat_id = h.id WHERE h.event_type = $1"+cond+" GROUP BY r.status", args...)
+	if err != nil {
+		panic(err)
+	}
+
+	counts := make(map[int]int)
+	for rows.Next() {
+		var status, count int
+		if err = rows.Scan(&status, &count); err != nil {
+			panic(err)
+		}
+		counts[status] = count
+	}
+	if err = rows.Close(); err != nil {
+		panic(err)
+	}
+	return counts
+}
+
+// Fraction of the starters in an event that did not finish
+func DNFRate(db *sql.DB, eventType internal.EventType, filter ResultFilter) float32 {
+	counts := StatusCounts(db, eventType, filter)
+	starters := 0
+	for status, count := range counts {
+		if status != internal.DNS {
+			starters += count
+		}
+	}
+	if starters == 0 {
+		return 0
+	}
+	return float32(counts[internal.DNF]) / float32(starters)
+}
+
+// An athlete's best mark in an event, or zero if they have no valid mark. Hand times are converted to their FAT equivalent
+func PersonalRecord(db *sql.DB, eventType internal.EventType, athID uint32, filter ResultFilter) float32 {
+	best := "MIN"
+	if eventType.HigherIsBetter() {
+		best = "MAX"
+	}
+	cond, args := filter.where([]interface{}{eventType, athID})
+	var pr sql.NullFloat64
+	row := db.QueryRow(fmt.Sprintf("SELECT %s(%s) FROM result r JOIN heat h ON r.heat_id = h.id WHERE h.event_type = $1 AND r.ath_id = $2", best, fatQuant(eventType))+cond, args...)
+	if err := row.Scan(&pr); err != nil {
+		panic(err)
+	}
+	return float32(pr.Float64)
+}
+
+// The best mark of each athlete in an event, ranked best first and limited to the top n athletes. Hand times are
+// ranked by their FAT equivalent but returned as recorded
+func Leaderboard(db *sql.DB, eventType internal.EventType, n int, filter ResultFilter) []internal.Result {
+	order := "ASC"
+	if eventType.HigherIsBetter() {
+		order = "DESC"
+	}
+	cond, args := filter.where([]interface{}{eventType})
+	args = append(args, n)
+	rows, err := db.Query(fmt.Sprintf(`SELECT id, heat_id, ath_id, quant, wind_ms, timing FROM (
+        SELECT DISTINCT ON (r.ath_id) r.id, r.heat_id, r.ath_id, r.quant, r.wind_ms, r.timing, %s AS fat
+        FROM result r JOIN heat h ON r.heat_id = h.id
+        WHERE h.event_type = $1 AND r.ath_id IS NOT NULL AND r.quant IS NOT NULL%s
+        ORDER BY r.ath_id, fat %s) best
+        ORDER BY fat %s LIMIT $%d`, fatQuant(eventType), cond, order, order, len(args)), args...)
+	if err != nil {
+		panic(err)
+	}
+
+	var leaders []internal.Result
+	for rows.Next() {
+		var (
+			result internal.Result
+			wind   sql.NullFloat64
+		)
+		if err = rows.Scan(&result.ID, &result.HeatID, &result.AthleteID, &result.Quantity, &wind, &result.Timing); err != nil {
+			panic(err)
+		}
+		if wind.Valid {
+			w := float32(wind.Float64)
+			result.WindMS = &w
+		}
+		leaders = append(leaders, result)
+	}
+	if err = rows.Close(); err != nil {
+		panic(err)
+	}
+	return leaders
+}
+
+// All of an athlete's results in an event ordered by the day they were run, including relays they ran a leg of
+func PersonalHistory(db *sql.DB, eventType internal.EventType, athID uint32, filter ResultFilter) []internal.Result {
+	cond, args := filter.where([]interface{}{eventType, athID})
+	rows, err := db.Query(`SELECT r.id, r.heat_id, COALESCE(r.ath_id, 0), COALESCE(r.pl, 0), COALESCE(r.quant, 0),
+        h.stage, r.status, COALESCE(r.dq_code, ''), r.timing, COALESCE(r.school_id, 0), COALESCE(r.team, ''),
+        ARRAY(SELECT COALESCE(l.ath_id, 0) FROM relay_leg l WHERE l.result_id = r.id ORDER BY l.leg)
+        FROM result r JOIN heat h ON r.heat_id = h.id JOIN meet m ON h.meet_id = m.id
+        WHERE h.event_type = $1 AND (r.ath_id = $2 OR EXISTS(SELECT 1 FROM relay_leg l WHERE l.result_id = r.id AND l.ath_id = $2))`+cond+`
+        ORDER BY COALESCE(h.date, m.date)`, args...)
+	if err != nil {
+		panic(err)
+	}
+
+	var history []internal.Result
+	for rows.Next() {
+		var (
+			result  internal.Result
+			members []int64
+		)
+		err = rows.Scan(&result.ID, &result.HeatID, &result.AthleteID, &result.Place, &result.Quantity,
+			&result.Stage, &result.Status, &result.DQCode, &result.Timing, &result.SchoolID, &result.Team, pq.Array(&members))
+		if err != nil {
+			panic(err)
+		}
+		for _, m := range members {
+			result.Members = append(result.Members, uint32(m))
+		}
+		history = append(history, result)
+	}
+	if err = rows.Close(); err != nil {
+		panic(err)
+	}
+	return history
 }
 
-func PersonalHistory(db *sql.DB, eventType internal.EventType, athID uint32) []float32 {
-	panic("Not implemented!")
+// The days an athlete competed on in date order, each with the events they contested that day. Heats without a
+// listed day are taken to be run on the meet's first day
+func raceDays(db *sql.DB, athID uint32, filter ResultFilter) ([]time.Time, [][]internal.EventType) {
+	cond, args := filter.where([]interface{}{athID})
+	rows, err := db.Query(`SELECT COALESCE(h.date, m.date) AS day, h.event_type
+        FROM result r JOIN heat h ON r.heat_id = h.id JOIN meet m ON h.meet_id = m.id
+        WHERE (r.ath_id = $1 OR EXISTS(SELECT 1 FROM relay_leg l WHERE l.result_id = r.id AND l.ath_id = $1))`+cond+`
+        GROUP BY day, h.event_type ORDER BY day, h.event_type`, args...)
+	if err != nil {
+		panic(err)
+	}
+
+	var (
+		days   []time.Time
+		events [][]internal.EventType
+	)
+	for rows.Next() {
+		var (
+			day       time.Time
+			eventType internal.EventType
+		)
+		if err = rows.Scan(&day, &eventType); err != nil {
+			panic(err)
+		}
+		day = day.UTC()
+		if len(days) == 0 || !days[len(days)-1].Equal(day) {
+			days = append(days, day)
+			events = append(events, nil)
+		}
+		events[len(events)-1] = append(events[len(events)-1], eventType)
+	}
+	if err = rows.Close(); err != nil {
+		panic(err)
+	}
+	return days, events
+}
+
+// Number of days between each of an athlete's consecutive race days, in date order
+func RecoveryIntervals(db *sql.DB, athID uint32, filter ResultFilter) []int {
+	days, _ := raceDays(db, athID, filter)
+	var intervals []int
+	for i := 1; i < len(days); i++ {
+		intervals = append(intervals, int(days[i].Sub(days[i-1]).Hours()/24))
+	}
+	return intervals
+}
+
+// The days an athlete contested more than one event, with the events contested each day
+func Doubles(db *sql.DB, athID uint32, filter ResultFilter) map[time.Time][]internal.EventType {
+	days, events := raceDays(db, athID, filter)
+	doubles := make(map[time.Time][]internal.EventType)
+	for i, day := range days {
+		if len(events[i]) > 1 {
+			doubles[day] = events[i]
+		}
+	}
+	return doubles
 }
@@ -1,8 +1,10 @@
 package database_test
 
 import (
+	"bactic/internal"
 	"bactic/internal/database"
 	"testing"
+	"time"
 )
 
 // Test that we can create and query a global performance histogram
@@ -33,7 +35,7 @@ func TestHistogram(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	hist := database.Histogram(db, 0, 3)
+	hist := database.Histogram(db, 0, 3, database.ResultFilter{})
 	expected := []int{1, 1, 1}
 	for i, h := range hist {
 		if expected[i] != h {
@@ -41,3 +43,351 @@ func TestHistogram(t *testing.T) {
 		}
 	}
 }
+
+// Test that attempt series can be summarized once inserted with their result
+func TestAttemptStats(t *testing.T) {
+	db := setupTestDB()
+	defer database.TeardownSchema(db)
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ath := internal.Athlete{ID: 123, Name: "Ath1"}
+	if err = database.InsertAthlete(tx, ath); err != nil {
+		t.Fatal(err)
+	}
+	meet := internal.Meet{
+		ID:   1234,
+		Name: "Bactic Championships",
+		Date: time.Date(2023, time.May, 6, 0, 0, 0, 0, time.UTC),
+	}
+	if err = database.InsertMeet(tx, meet); err != nil {
+		t.Fatal(err)
+	}
+
+	results := []internal.Result{{
+		AthleteID: ath.ID,
+		Quantity:  16.02,
+		Attempts: []internal.Attempt{
+			{Number: 1, Status: internal.FOUL},
+			{Number: 2, Quantity: 15.80},
+			{Number: 3, Quantity: 16.02},
+			{Number: 4, Status: internal.PASS},
+		},
+	}}
+	if _, err = database.InsertHeat(tx, internal.Heat{Type: internal.SHOT, MeetID: meet.ID}, results); err != nil {
+		t.Fatal(err)
+	}
+	if err = tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	var resultID uint32
+	if err = db.QueryRow("SELECT id FROM result WHERE ath_id = $1", ath.ID).Scan(&resultID); err != nil {
+		t.Fatal(err)
+	}
+
+	if round := database.BestMarkRound(db, resultID); round != 3 {
+		t.Errorf("Expected best mark in round 3 but got %d", round)
+	}
+	if rate := database.FoulRate(db, internal.SHOT, ath.ID, database.ResultFilter{}); rate < 0.33 || rate > 0.34 {
+		t.Errorf("Expected a foul rate of 1/3 but got %f", rate)
+	}
+	if stddev := database.SeriesConsistency(db, resultID); stddev < 0.10 || stddev > 0.12 {
+		t.Errorf("Expected a series deviation of 0.11 but got %f", stddev)
+	}
+}
+
+// Test that relay legs show up in their athletes' histories alongside individual results
+func TestPersonalHistoryRelays(t *testing.T) {
+	db := setupTestDB()
+	defer database.TeardownSchema(db)
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []uint32{1, 2, 3, 4} {
+		if err = database.InsertAthlete(tx, internal.Athlete{ID: id, Name: "Ath"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	school := internal.School{ID: 5, Name: "School", Division: internal.DIII, URL: "https://www.tfrrs.org/school_a"}
+	if err = database.InsertSchool(tx, school); err != nil {
+		t.Fatal(err)
+	}
+	meet := internal.Meet{
+		ID:   1234,
+		Name: "Bactic Championships",
+		Date: time.Date(2023, time.May, 6, 0, 0, 0, 0, time.UTC),
+	}
+	if err = database.InsertMeet(tx, meet); err != nil {
+		t.Fatal(err)
+	}
+
+	relay := []internal.Result{{
+		Place:    1,
+		Quantity: 193.76,
+		SchoolID: school.ID,
+		Team:     "A",
+		Members:  []uint32{1, 2, 3, 4},
+		Splits:   []float32{49.10, 48.21, 48.90, 47.55},
+	}}
+	if _, err = database.InsertHeat(tx, internal.Heat{Type: internal.T4X400, MeetID: meet.ID}, relay); err != nil {
+		t.Fatal(err)
+	}
+	if err = tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	history := database.PersonalHistory(db, internal.T4X400, 3, database.ResultFilter{})
+	if len(history) != 1 {
+		t.Fatalf("Expected one relay appearance but found %d", len(history))
+	}
+	if history[0].SchoolID != school.ID || len(history[0].Members) != 4 || history[0].Members[2] != 3 {
+		t.Errorf("Unexpected relay result in history %+v", history[0])
+	}
+}
+
+// Test that men's and women's results of the same event are kept apart by the sex filter
+func TestHistogramSexFilter(t *testing.T) {
+	db := setupTestDB()
+	defer database.TeardownSchema(db)
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []uint32{1, 2, 3} {
+		if err = database.InsertAthlete(tx, internal.Athlete{ID: id, Name: "Ath"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	meet := internal.Meet{
+		ID:   1234,
+		Name: "Bactic Championships",
+		Date: time.Date(2023, time.May, 6, 0, 0, 0, 0, time.UTC),
+	}
+	if err = database.InsertMeet(tx, meet); err != nil {
+		t.Fatal(err)
+	}
+
+	men := []internal.Result{{AthleteID: 1, Quantity: 14 * 60}, {AthleteID: 2, Quantity: 15 * 60}}
+	women := []internal.Result{{AthleteID: 3, Quantity: 16 * 60}}
+	if _, err = database.InsertHeat(tx, internal.Heat{Type: internal.T5000M, MeetID: meet.ID, Sex: internal.MEN}, men); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = database.InsertHeat(tx, internal.Heat{Type: internal.T5000M, MeetID: meet.ID, Sex: internal.WOMEN}, women); err != nil {
+		t.Fatal(err)
+	}
+	if err = tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	var total int
+	for _, h := range database.Histogram(db, internal.T5000M, 2, database.ResultFilter{Sex: internal.MEN}) {
+		total += h
+	}
+	if total != len(men) {
+		t.Errorf("Expected %d men's results in the histogram but found %d", len(men), total)
+	}
+}
+
+// Test that wind-aided marks can be left out of leaderboards and personal records
+func TestLeaderboardWindLegal(t *testing.T) {
+	db := setupTestDB()
+	defer database.TeardownSchema(db)
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []uint32{1, 2} {
+		if err = database.InsertAthlete(tx, internal.Athlete{ID: id, Name: "Ath"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	meet := internal.Meet{
+		ID:   1234,
+		Name: "Bactic Championships",
+		Date: time.Date(2023, time.May, 6, 0, 0, 0, 0, time.UTC),
+	}
+	if err = database.InsertMeet(tx, meet); err != nil {
+		t.Fatal(err)
+	}
+
+	aided, legal := float32(3.1), float32(1.2)
+	results := []internal.Result{
+		{AthleteID: 1, Quantity: 10.05, WindMS: &aided},
+		{AthleteID: 1, Quantity: 10.31, WindMS: &legal},
+		{AthleteID: 2, Quantity: 10.20, WindMS: &legal},
+	}
+	if _, err = database.InsertHeat(tx, internal.Heat{Type: internal.T100M, MeetID: meet.ID}, results); err != nil {
+		t.Fatal(err)
+	}
+	// a mark with no wind reading is not known to be wind-legal
+	if _, err = database.InsertHeat(tx, internal.Heat{Type: internal.T100M, MeetID: meet.ID, Section: 2}, []internal.Result{{AthleteID: 2, Quantity: 9.99}}); err != nil {
+		t.Fatal(err)
+	}
+	if err = tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	if pr := database.PersonalRecord(db, internal.T100M, 2, database.ResultFilter{WindLegal: true}); pr < 10.19 || pr > 10.21 {
+		t.Errorf("Expected a wind-legal record of 10.20 without the unread mark but got %f", pr)
+	}
+	if pr := database.PersonalRecord(db, internal.T100M, 1, database.ResultFilter{}); pr < 10.04 || pr > 10.06 {
+		t.Errorf("Expected an all-conditions record of 10.05 but got %f", pr)
+	}
+	if pr := database.PersonalRecord(db, internal.T100M, 1, database.ResultFilter{WindLegal: true}); pr < 10.30 || pr > 10.32 {
+		t.Errorf("Expected a wind-legal record of 10.31 but got %f", pr)
+	}
+
+	leaders := database.Leaderboard(db, internal.T100M, 2, database.ResultFilter{WindLegal: true})
+	if len(leaders) != 2 || leaders[0].AthleteID != 2 {
+		t.Errorf("Expected athlete 2 to lead the wind-legal leaderboard but got %+v", leaders)
+	}
+}
+
+func TestStatusCounts(t *testing.T) {
+	db := setupTestDB()
+	defer database.TeardownSchema(db)
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []uint32{1, 2, 3, 4, 5} {
+		if err = database.InsertAthlete(tx, internal.Athlete{ID: id, Name: "Ath"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	meet := internal.Meet{
+		ID:   1234,
+		Name: "Bactic Championships",
+		Date: time.Date(2023, time.May, 6, 0, 0, 0, 0, time.UTC),
+	}
+	if err = database.InsertMeet(tx, meet); err != nil {
+		t.Fatal(err)
+	}
+
+	results := []internal.Result{
+		{AthleteID: 1, Place: 1, Quantity: 600.5},
+		{AthleteID: 2, Place: 2, Quantity: 610.2},
+		{AthleteID: 3, Status: internal.DNF},
+		{AthleteID: 4, Status: internal.DNS},
+		{AthleteID: 5, Status: internal.DQ, DQCode: "163-3a"},
+	}
+	if _, err = database.InsertHeat(tx, internal.Heat{Type: internal.T3000S, MeetID: meet.ID}, results); err != nil {
+		t.Fatal(err)
+	}
+	if err = tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	counts := database.StatusCounts(db, internal.T3000S, database.ResultFilter{})
+	if counts[internal.VALID] != 2 || counts[internal.DNF] != 1 || counts[internal.DNS] != 1 || counts[internal.DQ] != 1 {
+		t.Errorf("Unexpected status counts %v", counts)
+	}
+	if rate := database.DNFRate(db, internal.T3000S, database.ResultFilter{}); rate < 0.24 || rate > 0.26 {
+		t.Errorf("Expected a DNF rate of 0.25 but got %f", rate)
+	}
+
+	history := database.PersonalHistory(db, internal.T3000S, 5, database.ResultFilter{})
+	if len(history) != 1 || history[0].Status != internal.DQ || history[0].DQCode != "163-3a" {
+		t.Errorf("Expected a disqualification under rule 163-3a but got %+v", history)
+	}
+}
+
+func TestLeaderboardTiming(t *testing.T) {
+	db := setupTestDB()
+	defer database.TeardownSchema(db)
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []uint32{1, 2} {
+		if err = database.InsertAthlete(tx, internal.Athlete{ID: id, Name: "Ath"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	meet := internal.Meet{
+		ID:   1234,
+		Name: "Bactic Championships",
+		Date: time.Date(2023, time.May, 6, 0, 0, 0, 0, time.UTC),
+	}
+	if err = database.InsertMeet(tx, meet); err != nil {
+		t.Fatal(err)
+	}
+
+	// a 10.7 hand time converts to 10.94, behind a 10.90 FAT time
+	results := []internal.Result{
+		{AthleteID: 1, Quantity: 10.7, Timing: internal.HAND},
+		{AthleteID: 2, Quantity: 10.90},
+	}
+	if _, err = database.InsertHeat(tx, internal.Heat{Type: internal.T100M, MeetID: meet.ID}, results); err != nil {
+		t.Fatal(err)
+	}
+	if err = tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	leaders := database.Leaderboard(db, internal.T100M, 2, database.ResultFilter{})
+	if len(leaders) != 2 || leaders[0].AthleteID != 2 || leaders[1].Timing != internal.HAND {
+		t.Errorf("Expected the FAT time to lead the hand time but got %+v", leaders)
+	}
+	leaders = database.Leaderboard(db, internal.T100M, 2, database.ResultFilter{RequireFAT: true})
+	if len(leaders) != 1 || leaders[0].AthleteID != 2 {
+		t.Errorf("Expected only the FAT time but got %+v", leaders)
+	}
+	if pr := database.PersonalRecord(db, internal.T100M, 1, database.ResultFilter{}); pr < 10.93 || pr > 10.95 {
+		t.Errorf("Expected a FAT equivalent record of 10.94 but got %f", pr)
+	}
+}
+
+func TestRaceDays(t *testing.T) {
+	db := setupTestDB()
+	defer database.TeardownSchema(db)
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = database.InsertAthlete(tx, internal.Athlete{ID: 1, Name: "Ath"}); err != nil {
+		t.Fatal(err)
+	}
+	meet := internal.Meet{
+		ID:      1234,
+		Name:    "Bactic Championships",
+		Date:    time.Date(2023, time.May, 25, 0, 0, 0, 0, time.UTC),
+		EndDate: time.Date(2023, time.May, 27, 0, 0, 0, 0, time.UTC),
+	}
+	if err = database.InsertMeet(tx, meet); err != nil {
+		t.Fatal(err)
+	}
+
+	heats := []internal.Heat{
+		{Type: internal.T1500M, MeetID: meet.ID, Stage: internal.PRELIM, Date: time.Date(2023, time.May, 25, 0, 0, 0, 0, time.UTC)},
+		{Type: internal.T1500M, MeetID: meet.ID, Stage: internal.FINAL, Date: time.Date(2023, time.May, 27, 0, 0, 0, 0, time.UTC)},
+		{Type: internal.T5000M, MeetID: meet.ID, Stage: internal.FINAL, Date: time.Date(2023, time.May, 27, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, heat := range heats {
+		if _, err = database.InsertHeat(tx, heat, []internal.Result{{AthleteID: 1, Quantity: 240}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	intervals := database.RecoveryIntervals(db, 1, database.ResultFilter{})
+	if len(intervals) != 1 || intervals[0] != 2 {
+		t.Errorf("Expected a single 2 day interval but got %v", intervals)
+	}
+	doubles := database.Doubles(db, 1, database.ResultFilter{})
+	if events := doubles[heats[1].Date]; len(doubles) != 1 || len(events) != 2 {
+		t.Errorf("Expected a 1500m and 5000m double on the last day but got %v", doubles)
+	}
+}
@@ -0,0 +1,485 @@
+package athnet
+
+import (
+	"bactic/internal"
+	"bactic/internal/scrapers"
+	"fmt"
+	"math"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Sports athletic.net lists meets under, as they appear in its api and in our meet keys
+const (
+	sportXC = "xc"
+	sportTF = "tf"
+)
+
+// Results of a meet as served by the athletic.net results api
+type meetData struct {
+	Meet       meetInfo     `json:"meet"`
+	Events     []eventInfo  `json:"events"`
+	Results    []resultInfo `json:"results"`
+	TeamScores []teamScore  `json:"teamScores"`
+}
+
+type meetInfo struct {
+	MeetID int
+	Name   string
+	// Dates as 2006-01-02T15:04:05, the end date empty for single-day meets
+	StartDate string
+	EndDate   string
+	Location  struct {
+		Name  string
+		City  string
+		State string
+	}
+	HostName string
+}
+
+// A race of a cross country meet, or an event of a track meet. Meets can hold several races of an event, such as
+// varsity and junior varsity, which are told apart by their division
+type eventInfo struct {
+	EventID int
+	// Event as listed, such as 5,000 Meters or Shot Put - 12lb
+	Event string
+	// M or F
+	Gender   string
+	Division string
+	// Prelims or Finals, empty for events contested in a single round
+	Round string
+}
+
+type resultInfo struct {
+	EventID int
+	// Heat of a prelim or section of a timed final, zero if not listed
+	Heat       int
+	AthleteID  int
+	FirstName  string
+	LastName   string
+	TeamID     int
+	SchoolName string
+	Place      int
+	// Time or mark as listed, such as 15:41.2, 11.2h, 45-06.25 or DNF
+	Result string
+	Wind   *float32
+	// Relay designation, with the legs in running order
+	Relay string
+	Legs  []legInfo
+}
+
+type legInfo struct {
+	AthleteID int
+	FirstName string
+	LastName  string
+}
+
+// A team's standing in a scored cross country race
+type teamScore struct {
+	EventID    int
+	TeamID     int
+	SchoolName string
+	Place      int
+	Points     int
+}
+
+// A meet listed on a team's calendar or an athlete's results history
+type listedMeet struct {
+	MeetID    int
+	Name      string
+	StartDate string
+	EndDate   string
+	// Sport of the meet, only listed in athlete histories
+	Sport         string
+	ResultsPosted bool
+}
+
+// Meets listed on an athlete's page, covering every season they competed in
+type athleteBio struct {
+	Meets []listedMeet `json:"meets"`
+}
+
+const dateLayout = "2006-01-02T15:04:05"
+
+// Parse the start and end dates of a meet. Single-day meets end on their start date
+func parseMeetDates(start string, end string) (time.Time, time.Time, error) {
+	startDate, err := time.Parse(dateLayout, start)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("could not parse meet date %q: %w", start, err)
+	}
+	if len(end) == 0 {
+		return startDate, startDate, nil
+	}
+	endDate, err := time.Parse(dateLayout, end)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("could not parse meet end date %q: %w", end, err)
+	}
+	return startDate, endDate, nil
+}
+
+// Reference to the results of a meet in the api at base
+func meetRef(base string, sport string, meetID int, name string, date time.Time, endDate time.Time) scrapers.MeetRef {
+	return scrapers.MeetRef{
+		Key:     fmt.Sprintf("athnet/%s/%d", sport, meetID),
+		Name:    name,
+		URL:     fmt.Sprintf("%s/Meet/GetResultsData?meetId=%d&sport=%s", base, meetID, sport),
+		Date:    date,
+		EndDate: endDate,
+	}
+}
+
+// Parse a meet given as an option, such as xc/219417
+func parseMeetOption(base string, option string) (scrapers.MeetRef, error) {
+	sport, id, found := strings.Cut(strings.TrimSpace(option), "/")
+	meetID, err := strconv.Atoi(id)
+	if !found || err != nil || (sport != sportXC && sport != sportTF) {
+		return scrapers.MeetRef{}, fmt.Errorf("meet %q is not of the form xc/<meet id> or tf/<meet id>", option)
+	}
+	return meetRef(base, sport, meetID, "", time.Time{}, time.Time{}), nil
+}
+
+// Parse a meet listed on a calendar or in an athlete's history of the sport
+func parseListedMeet(base string, sport string, meet listedMeet) (scrapers.MeetRef, error) {
+	if sport != sportXC && sport != sportTF {
+		return scrapers.MeetRef{}, fmt.Errorf("meet %d is listed under unknown sport %q", meet.MeetID, sport)
+	}
+	date, endDate, err := parseMeetDates(meet.StartDate, meet.EndDate)
+	if err != nil {
+		return scrapers.MeetRef{}, err
+	}
+	return meetRef(base, sport, meet.MeetID, meet.Name, date, endDate), nil
+}
+
+// Sport of a meet from its key, such as the xc of athnet/xc/219417
+func parseSport(key string) (string, error) {
+	parts := strings.Split(key, "/")
+	if len(parts) != 3 || parts[0] != "athnet" || (parts[1] != sportXC && parts[1] != sportTF) {
+		return "", fmt.Errorf("%s is not an athletic.net meet key", key)
+	}
+	return parts[1], nil
+}
+
+// Parse the details of a meet. The season of a track meet is only known once its events are read
+func parseMeet(info meetInfo, meetID uint32, ref scrapers.MeetRef) (internal.Meet, error) {
+	meet := internal.Meet{
+		ID:      meetID,
+		Name:    strings.TrimSpace(info.Name),
+		Date:    ref.Date,
+		EndDate: ref.EndDate,
+		Venue:   strings.TrimSpace(info.Location.Name),
+		City:    strings.TrimSpace(info.Location.City),
+		State:   strings.TrimSpace(info.Location.State),
+		Host:    strings.TrimSpace(info.HostName),
+	}
+	if len(meet.Name) == 0 {
+		meet.Name = ref.Name
+	}
+	if len(info.StartDate) > 0 {
+		var err error
+		if meet.Date, meet.EndDate, err = parseMeetDates(info.StartDate, info.EndDate); err != nil {
+			return meet, err
+		}
+	}
+	if meet.Date.IsZero() {
+		return meet, fmt.Errorf("meet %s has no date", ref.Key)
+	}
+	return meet, nil
+}
+
+var (
+	// distances such as 5,000 Meters or 100m, read as 5000m and 100m
+	distanceRe   = regexp.MustCompile(`(\d+)\s*(?:meters?|m)\b`)
+	whitespaceRe = regexp.MustCompile(`\s+`)
+)
+
+// Normalize an event name for lookup, dropping the implement or hurdle height that follows a dash, as in Shot Put -
+// 12lb. The spec is checked separately by checkSpec
+func normalizeEvent(event string) string {
+	event, _, _ = strings.Cut(strings.ToLower(event), " - ")
+	event = strings.ReplaceAll(event, ",", "")
+	event = distanceRe.ReplaceAllString(event, "${1}m")
+	return strings.TrimSpace(whitespaceRe.ReplaceAllString(event, " "))
+}
+
+var xcEvents = map[string]internal.EventType{
+	"5000m":  internal.XC_5K,
+	"5k":     internal.XC_5K,
+	"6000m":  internal.XC_6K,
+	"6k":     internal.XC_6K,
+	"8000m":  internal.XC_8K,
+	"8k":     internal.XC_8K,
+	"10000m": internal.XC_10K,
+	"10k":    internal.XC_10K,
+}
+
+var tfEvents = map[string]internal.EventType{
+	"60m":                   internal.T60M,
+	"100m":                  internal.T100M,
+	"200m":                  internal.T200M,
+	"300m":                  internal.T300M,
+	"400m":                  internal.T400M,
+	"500m":                  internal.T500M,
+	"600m":                  internal.T600M,
+	"800m":                  internal.T800M,
+	"1000m":                 internal.T1000M,
+	"1500m":                 internal.T1500M,
+	"1600m":                 internal.T1600M,
+	"mile":                  internal.MILE,
+	"1 mile":                internal.MILE,
+	"3000m":                 internal.T3000M,
+	"3200m":                 internal.T3200M,
+	"5000m":                 internal.T5000M,
+	"10000m":                internal.T10000M,
+	"60m hurdles":           internal.T60H,
+	"100m hurdles":          internal.T100H,
+	"110m hurdles":          internal.T110H,
+	"300m hurdles":          internal.T300H,
+	"400m hurdles":          internal.T400H,
+	"3000m steeplechase":    internal.T3000S,
+	"4x100 relay":           internal.T4X100,
+	"4x100m relay":          internal.T4X100,
+	"4x400 relay":           internal.T4X400,
+	"4x400m relay":          internal.T4X400,
+	"4x800 relay":           internal.T4X800,
+	"4x800m relay":          internal.T4X800,
+	"distance medley relay": internal.DMR,
+	"dmr":                   internal.DMR,
+	"high jump":             internal.HIGH_JUMP,
+	"pole vault":            internal.VAULT,
+	"long jump":             internal.LONG_JUMP,
+	"triple jump":           internal.TRIPLE_JUMP,
+	"shot put":              internal.SHOT,
+	"discus":                internal.DISCUS,
+	"hammer":                internal.HAMMER,
+	"javelin":               internal.JAV,
+	"weight throw":          internal.WEIGHT,
+}
+
+// Parse the event type of an event of the sport. Events we have no type for, such as the 2 mile cross country race
+// or the 4x200, are an error
+func parseEventType(event string, sport string) (internal.EventType, error) {
+	events := tfEvents
+	if sport == sportXC {
+		events = xcEvents
+	}
+	if eventType, found := events[normalizeEvent(event)]; found {
+		return eventType, nil
+	}
+	return 0, fmt.Errorf("unsupported %s event %q", sport, event)
+}
+
+// Parse an event into its heat, without the heat number
+func parseEvent(event eventInfo, sport string) (internal.Heat, error) {
+	eventType, err := parseEventType(event.Event, sport)
+	if err != nil {
+		return internal.Heat{}, err
+	}
+	heat := internal.Heat{Type: eventType, Stage: internal.FINAL}
+	switch strings.ToUpper(strings.TrimSpace(event.Gender)) {
+	case "M":
+		heat.Sex = internal.MEN
+	case "F", "W":
+		heat.Sex = internal.WOMEN
+	default:
+		return internal.Heat{}, fmt.Errorf("unknown sex %q for event %q", event.Gender, event.Event)
+	}
+	switch strings.ToLower(strings.TrimSpace(event.Round)) {
+	case "prelims", "prelim", "p":
+		heat.Stage = internal.PRELIM
+	case "semifinals", "semifinal", "s":
+		heat.Stage = internal.SEMIFINAL
+	}
+	if err = checkSpec(event.Event, heat.Type, heat.Sex); err != nil {
+		return internal.Heat{}, err
+	}
+	return heat, nil
+}
+
+var specRe = regexp.MustCompile(`(\d+(?:\.\d+)?)\s*(lbs?|kg|g|"|in|cm|m)`)
+
+// Implement weights in kilograms, and hurdle heights in meters, of collegiate events by sex
+var collegiateSpecs = map[internal.EventType]map[int]float64{
+	internal.SHOT:   {internal.MEN: 7.26, internal.WOMEN: 4},
+	internal.DISCUS: {internal.MEN: 2, internal.WOMEN: 1},
+	internal.HAMMER: {internal.MEN: 7.26, internal.WOMEN: 4},
+	internal.JAV:    {internal.MEN: 0.8, internal.WOMEN: 0.6},
+	internal.WEIGHT: {internal.MEN: 15.88, internal.WOMEN: 9.07},
+	internal.T60H:   {internal.MEN: 1.067, internal.WOMEN: 0.838},
+	internal.T100H:  {internal.WOMEN: 0.838},
+	internal.T110H:  {internal.MEN: 1.067},
+	internal.T400H:  {internal.MEN: 0.914, internal.WOMEN: 0.762},
+	internal.T3000S: {internal.MEN: 0.914, internal.WOMEN: 0.762},
+}
+
+// Check the implement or hurdle height listed after an event's name, as in Shot Put - 12lb, against the collegiate
+// spec of the event. High school events such as the boys' 12lb shot or 39" hurdles are an error, so that their marks
+// are not stored under the collegiate event. Events listed without a spec are taken to be run to it
+func checkSpec(event string, eventType internal.EventType, sex int) error {
+	_, spec, found := strings.Cut(strings.ToLower(event), " - ")
+	expected, checked := collegiateSpecs[eventType][sex]
+	if !found || !checked {
+		return nil
+	}
+	matches := specRe.FindStringSubmatch(spec)
+	if matches == nil {
+		return fmt.Errorf("unknown spec %q of event %q", spec, event)
+	}
+	value, _ := strconv.ParseFloat(matches[1], 64)
+	switch matches[2] {
+	case "lb", "lbs":
+		value *= 0.45359237
+	case "g":
+		value /= 1000
+	case "\"", "in":
+		value *= 0.0254
+	case "cm":
+		value /= 100
+	}
+	if math.Abs(value-expected) > 0.02*expected {
+		return fmt.Errorf("event %q is not run to the collegiate spec", event)
+	}
+	return nil
+}
+
+var (
+	timeRe         = regexp.MustCompile(`^(?:(\d+):)?(?:(\d+):)?(\d+)(?:\.(\d+))?\s*([hH])?$`)
+	metricMarkRe   = regexp.MustCompile(`^(\d+(?:\.\d+)?)\s*m$`)
+	feetInchMarkRe = regexp.MustCompile(`^(\d+)(?:-|'\s*)(\d+(?:\.\d+)?)"?$`)
+)
+
+// Marks recorded without a time or distance
+var markStatuses = map[string]int{
+	"DNF":  internal.DNF,
+	"DNS":  internal.DNS,
+	"SCR":  internal.DNS,
+	"DQ":   internal.DQ,
+	"FS":   internal.FS,
+	"NT":   internal.NT,
+	"FOUL": internal.FOUL,
+	"NH":   internal.NO_HEIGHT,
+	"ND":   internal.NO_DISTANCE,
+	"NM":   internal.NO_DISTANCE,
+}
+
+// Parse a time such as 15:41.2 or 11.2h into seconds and its timing method. Times marked with a trailing h are hand
+// timed, as are track times read to the tenth or whole second when inferHand is set
+func parseTime(t string, inferHand bool) (float32, int, error) {
+	matches := timeRe.FindStringSubmatch(t)
+	if matches == nil {
+		return 0, internal.FAT, fmt.Errorf("time could not be parsed: %s", t)
+	}
+	var hours, minutes int
+	if len(matches[1]) > 0 {
+		minutes, _ = strconv.Atoi(matches[1])
+	}
+	if len(matches[2]) > 0 {
+		hours = minutes
+		minutes, _ = strconv.Atoi(matches[2])
+	}
+	seconds, err := strconv.ParseFloat(matches[3]+"."+matches[4], 64)
+	if err != nil {
+		return 0, internal.FAT, err
+	}
+	timing := internal.FAT
+	if len(matches[5]) > 0 || (inferHand && len(matches[4]) < 2) {
+		timing = internal.HAND
+	}
+	return float32(float64(hours*3600+minutes*60) + seconds), timing, nil
+}
+
+// Parse a field event mark into meters. Marks are listed in feet and inches (45-06.25 or 45' 6.25") or in meters
+// (13.45m)
+func parseMark(m string) (float32, error) {
+	if matches := metricMarkRe.FindStringSubmatch(m); matches != nil {
+		meters, err := strconv.ParseFloat(matches[1], 64)
+		return float32(meters), err
+	}
+	if matches := feetInchMarkRe.FindStringSubmatch(m); matches != nil {
+		feet, err := strconv.ParseFloat(matches[1], 64)
+		if err != nil {
+			return 0, err
+		}
+		inches, err := strconv.ParseFloat(matches[2], 64)
+		if err != nil {
+			return 0, err
+		}
+		if inches >= 12 {
+			return 0, fmt.Errorf("mark %s has more than 12 inches", m)
+		}
+		return float32((feet*12 + inches) * 0.0254), nil
+	}
+	return 0, fmt.Errorf("mark could not be parsed into meters or feet-inches: %s", m)
+}
+
+// Parse the listed time or mark of a result in the event into the result. Marks such as DNF are kept as the result
+// status, along with the rule code of a disqualification such as DQ (163-3a)
+func parseResultMark(mark string, eventType internal.EventType, sport string, result *internal.Result) error {
+	mark = strings.TrimSpace(mark)
+	if fields := strings.Fields(mark); len(fields) > 0 {
+		if status, found := markStatuses[strings.ToUpper(fields[0])]; found {
+			result.Status = status
+			if status == internal.DQ && len(fields) > 1 {
+				result.DQCode = strings.Trim(strings.Join(fields[1:], " "), "()[]")
+			}
+			return nil
+		}
+	}
+
+	var err error
+	if eventType.IsField() {
+		result.Quantity, err = parseMark(mark)
+		return err
+	}
+	// cross country is timed to the tenth even when fully automatic
+	result.Quantity, result.Timing, err = parseTime(mark, sport == sportTF)
+	return err
+}
+
+// Parse a listed result, leaving its athlete, relay legs and school to be resolved. Relays are told apart by their
+// designation, while every other result must list its athlete
+func parseResult(listed resultInfo, eventType internal.EventType, sport string) (internal.Result, error) {
+	result := internal.Result{Place: listed.Place, WindMS: listed.Wind, Team: strings.TrimSpace(listed.Relay)}
+	if len(result.Team) == 0 && len(listed.Legs) == 0 && listed.AthleteID == 0 {
+		return result, fmt.Errorf("result %q lists no athlete", listed.Result)
+	}
+	if err := parseResultMark(listed.Result, eventType, sport, &result); err != nil {
+		return result, err
+	}
+	if result.Status != internal.VALID {
+		result.Place = 0
+		result.WindMS = nil
+	}
+	return result, nil
+}
+
+// Name of an athlete as listed
+func athleteName(first string, last string) string {
+	return strings.TrimSpace(strings.TrimSpace(first) + " " + strings.TrimSpace(last))
+}
+
+// Url a team is stored under in the school table, shared by its cross country and track pages
+func teamURL(teamID int) string {
+	return fmt.Sprintf("https://www.athletic.net/team/%d", teamID)
+}
+
+// Season of the sport whose calendar lists the meets of now. Cross country seasons are named for the year of their
+// fall, and track seasons for the year of their spring, so the track season begins with the indoor meets of the fall
+func calendarSeason(sport string, now time.Time) int {
+	if sport == sportTF && now.Month() >= time.September {
+		return now.Year() + 1
+	}
+	return now.Year()
+}
+
+// Url of the calendar of a team's meets in a season of the sport
+func calendarURL(base string, teamID string, sport string, season int) string {
+	return fmt.Sprintf("%s/TeamHome/GetCalendar?teamId=%s&sport=%s&season=%d", base, url.QueryEscape(teamID), sport, season)
+}
+
+// Url of an athlete's results history
+func athleteURL(base string, athleteID int) string {
+	return fmt.Sprintf("%s/AthleteBio/GetAthleteBioData?athleteId=%d", base, athleteID)
+}
@@ -0,0 +1,274 @@
+package athnet
+
+import (
+	"bactic/internal"
+	"encoding/json"
+	"io"
+	"log"
+	"math"
+	"os"
+	"testing"
+	"time"
+)
+
+func almostEqual(a, b float32) bool {
+	return math.Abs(float64(a-b)) < 1e-3
+}
+
+func readMeet(t *testing.T, path string) meetData {
+	body, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var data meetData
+	if err = json.Unmarshal(body, &data); err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestParseEventType(t *testing.T) {
+	events := []struct {
+		event     string
+		sport     string
+		eventType internal.EventType
+	}{
+		{"5,000 Meters", sportXC, internal.XC_5K},
+		{"5K", sportXC, internal.XC_5K},
+		{"8,000 Meters", sportXC, internal.XC_8K},
+		{"100 Meters", sportTF, internal.T100M},
+		{"1600 Meters", sportTF, internal.T1600M},
+		{"3200m", sportTF, internal.T3200M},
+		{"1 Mile", sportTF, internal.MILE},
+		{"110m Hurdles - 39\"", sportTF, internal.T110H},
+		{"300m Hurdles - 36\"", sportTF, internal.T300H},
+		{"4x400 Relay", sportTF, internal.T4X400},
+		{"4x800 Meter Relay", sportTF, internal.T4X800},
+		{"Shot Put - 12lb", sportTF, internal.SHOT},
+		{"Discus - 1.6kg", sportTF, internal.DISCUS},
+		{"Pole  Vault", sportTF, internal.VAULT},
+	}
+	for _, e := range events {
+		eventType, err := parseEventType(e.event, e.sport)
+		if err != nil {
+			t.Errorf("Unexpected error parsing event %s: %v", e.event, err)
+		} else if eventType != e.eventType {
+			t.Errorf("Event %s parsed to %v, expected %v", e.event, eventType, e.eventType)
+		}
+	}
+
+	for _, event := range []string{"2 Miles", "4K"} {
+		if _, err := parseEventType(event, sportXC); err == nil {
+			t.Errorf("Expected cross country event %s to be unsupported", event)
+		}
+	}
+	if _, err := parseEventType("100 Meters", sportXC); err == nil {
+		t.Error("Expected a track event to be unsupported in cross country")
+	}
+}
+
+func TestParseEvent(t *testing.T) {
+	heat, err := parseEvent(eventInfo{Event: "100 Meters", Gender: "F", Round: "Prelims"}, sportTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if heat.Type != internal.T100M || heat.Sex != internal.WOMEN || heat.Stage != internal.PRELIM {
+		t.Errorf("Unexpected heat %+v", heat)
+	}
+	if heat, err = parseEvent(eventInfo{Event: "5K", Gender: "M"}, sportXC); err != nil || heat.Stage != internal.FINAL || heat.Sex != internal.MEN {
+		t.Errorf("Expected a men's final but got %+v, %v", heat, err)
+	}
+	if _, err = parseEvent(eventInfo{Event: "5K", Gender: "X"}, sportXC); err == nil {
+		t.Error("Expected an error for an unknown sex")
+	}
+
+	// events run to a high school spec are not stored under the collegiate event
+	specs := []struct {
+		event      string
+		sex        string
+		collegiate bool
+	}{
+		{"Shot Put - 12lb", "M", false},
+		{"Shot Put - 16lb", "M", true},
+		{"Shot Put - 4kg", "F", true},
+		{"Discus - 1.6kg", "M", false},
+		{"Discus - 1kg", "F", true},
+		{"110m Hurdles - 39\"", "M", false},
+		{"110m Hurdles - 42\"", "M", true},
+		{"100m Hurdles - 33\"", "F", true},
+		{"300m Hurdles - 36\"", "M", true},
+		{"Shot Put", "M", true},
+	}
+	for _, spec := range specs {
+		_, err := parseEvent(eventInfo{Event: spec.event, Gender: spec.sex}, sportTF)
+		if spec.collegiate && err != nil {
+			t.Errorf("Unexpected error parsing event %s: %v", spec.event, err)
+		} else if !spec.collegiate && err == nil {
+			t.Errorf("Expected event %s to be skipped", spec.event)
+		}
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	times := []struct {
+		time      string
+		inferHand bool
+		seconds   float32
+		timing    int
+	}{
+		{"15:41.2", false, 941.2, internal.FAT},
+		{"10.71", true, 10.71, internal.FAT},
+		{"10.8", true, 10.8, internal.HAND},
+		{"10.8h", false, 10.8, internal.HAND},
+		{"4:45.82", true, 285.82, internal.FAT},
+		{"1:02:03", false, 3723, internal.FAT},
+	}
+	for _, tt := range times {
+		seconds, timing, err := parseTime(tt.time, tt.inferHand)
+		if err != nil {
+			t.Fatalf("Unexpected error parsing time %s: %v", tt.time, err)
+		}
+		if !almostEqual(seconds, tt.seconds) || timing != tt.timing {
+			t.Errorf("Time %s parsed to %f with timing %d, expected %f with timing %d", tt.time, seconds, timing, tt.seconds, tt.timing)
+		}
+	}
+	if _, _, err := parseTime("15:41.2 PR", false); err == nil {
+		t.Error("Expected a time with trailing text to fail parsing")
+	}
+}
+
+func TestParseMark(t *testing.T) {
+	marks := map[string]float32{
+		"5.82m":    5.82,
+		"58-06.25": 17.8371,
+		"6' 2\"":   1.8796,
+		"145-10":   44.4500,
+		"13.4 m":   13.4,
+	}
+	for m, expected := range marks {
+		meters, err := parseMark(m)
+		if err != nil {
+			t.Fatalf("Unexpected error parsing mark %s: %v", m, err)
+		}
+		if !almostEqual(meters, expected) {
+			t.Errorf("Mark %s parsed to %f, expected %f", m, meters, expected)
+		}
+	}
+	for _, m := range []string{"", "FOUL", "6-13", "5.82"} {
+		if _, err := parseMark(m); err == nil {
+			t.Errorf("Expected mark %s to fail parsing", m)
+		}
+	}
+}
+
+func TestParseResult(t *testing.T) {
+	result, err := parseResult(resultInfo{AthleteID: 1, Place: 3, Result: "10.8", Wind: new(float32)}, internal.T100M, sportTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Place != 3 || result.Status != internal.VALID || !almostEqual(result.Quantity, 10.8) || result.Timing != internal.HAND || result.WindMS == nil {
+		t.Errorf("Unexpected result %+v", result)
+	}
+
+	statuses := map[string]int{
+		"DNF":        internal.DNF,
+		"DNS":        internal.DNS,
+		"SCR":        internal.DNS,
+		"FS":         internal.FS,
+		"FOUL":       internal.FOUL,
+		"NM":         internal.NO_DISTANCE,
+		"DQ (168-7)": internal.DQ,
+	}
+	for mark, status := range statuses {
+		result, err := parseResult(resultInfo{AthleteID: 1, Place: 2, Result: mark, Wind: new(float32)}, internal.T300H, sportTF)
+		if err != nil {
+			t.Fatalf("Unexpected error parsing mark %s: %v", mark, err)
+		}
+		if result.Status != status || result.Place != 0 || result.WindMS != nil {
+			t.Errorf("Mark %s parsed to %+v, expected status %d without a place or wind", mark, result, status)
+		}
+	}
+	if result, _ = parseResult(resultInfo{AthleteID: 1, Result: "DQ (168-7)"}, internal.T300H, sportTF); result.DQCode != "168-7" {
+		t.Errorf("Expected rule code 168-7 but got %q", result.DQCode)
+	}
+
+	relay, err := parseResult(resultInfo{Relay: "A", Result: "3:15.40", Legs: []legInfo{{AthleteID: 1}}}, internal.T4X400, sportTF)
+	if err != nil || relay.Team != "A" {
+		t.Errorf("Expected relay A but got %+v, %v", relay, err)
+	}
+	if _, err = parseResult(resultInfo{Result: "18:01.0"}, internal.XC_5K, sportXC); err == nil {
+		t.Error("Expected an error for a result without an athlete")
+	}
+}
+
+func TestParseMeet(t *testing.T) {
+	data := readMeet(t, "../../../test/athnet/tf_meet.json")
+	meet, err := parseMeet(data.Meet, 7, meetRef(apiURL, sportTF, 502311, "", time.Time{}, time.Time{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meet.ID != 7 || meet.Name != "Arcadia Invitational" || meet.Venue != "Arcadia High School" || meet.City != "Arcadia" ||
+		meet.State != "CA" || meet.Host != "Arcadia" {
+		t.Errorf("Unexpected meet %+v", meet)
+	}
+	if !meet.Date.Equal(time.Date(2023, time.April, 7, 0, 0, 0, 0, time.UTC)) || !meet.EndDate.Equal(time.Date(2023, time.April, 8, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected meet dates %v to %v", meet.Date, meet.EndDate)
+	}
+
+	data = readMeet(t, "../../../test/athnet/xc_meet.json")
+	if meet, err = parseMeet(data.Meet, 7, meetRef(apiURL, sportXC, 219417, "", time.Time{}, time.Time{})); err != nil || !meet.EndDate.Equal(meet.Date) {
+		t.Errorf("Expected a single-day meet but got %+v, %v", meet, err)
+	}
+	if _, err = parseMeet(meetInfo{}, 7, meetRef(apiURL, sportXC, 1, "", time.Time{}, time.Time{})); err == nil {
+		t.Error("Expected an error for a meet without a date")
+	}
+}
+
+func TestGroupResults(t *testing.T) {
+	logger := log.New(io.Discard, "", 0)
+
+	xc := groupResults(readMeet(t, "../../../test/athnet/xc_meet.json"), sportXC, logger)
+	// the 2 mile race has no event type and is skipped
+	if len(xc) != 3 {
+		t.Fatalf("Expected 3 races but got %d", len(xc))
+	}
+	// the varsity and junior varsity 5Ks are numbered in listed order
+	if xc[0].heat.Number != 0 || xc[1].heat.Number != 1 || xc[2].heat.Number != 0 {
+		t.Errorf("Unexpected race numbers %d, %d and %d", xc[0].heat.Number, xc[1].heat.Number, xc[2].heat.Number)
+	}
+	if len(xc[0].heats) != 1 || len(xc[0].results[0]) != 3 {
+		t.Errorf("Expected the varsity race to hold 3 results in one heat but got %v", xc[0].results)
+	}
+
+	tf := groupResults(readMeet(t, "../../../test/athnet/tf_meet.json"), sportTF, logger)
+	// the boys' 12lb shot put is skipped
+	if len(tf) != 6 {
+		t.Fatalf("Expected 6 events but got %d", len(tf))
+	}
+	prelims := tf[0]
+	if len(prelims.heats) != 2 || prelims.heatOf(2).Number != 2 || prelims.heatOf(2).Stage != internal.PRELIM {
+		t.Errorf("Expected two numbered prelim heats but got %v", prelims.heats)
+	}
+	if final := tf[1].heatOf(3); final.Number != 0 || final.Section != 3 {
+		t.Errorf("Expected a section of the final but got %+v", final)
+	}
+}
+
+func TestCalendarSeason(t *testing.T) {
+	tests := []struct {
+		sport  string
+		date   time.Time
+		season int
+	}{
+		{sportXC, time.Date(2024, time.October, 5, 0, 0, 0, 0, time.UTC), 2024},
+		{sportTF, time.Date(2024, time.October, 5, 0, 0, 0, 0, time.UTC), 2025},
+		{sportTF, time.Date(2024, time.December, 7, 0, 0, 0, 0, time.UTC), 2025},
+		{sportTF, time.Date(2025, time.February, 15, 0, 0, 0, 0, time.UTC), 2025},
+		{sportTF, time.Date(2025, time.May, 10, 0, 0, 0, 0, time.UTC), 2025},
+	}
+	for _, test := range tests {
+		if season := calendarSeason(test.sport, test.date); season != test.season {
+			t.Errorf("Expected the %s calendar of %v to be season %d but got %d", test.sport, test.date, test.season, season)
+		}
+	}
+}
@@ -0,0 +1,465 @@
+package athnet
+
+import (
+	"bactic/internal"
+	"bactic/internal/database"
+	"bactic/internal/scrapers"
+	"bactic/internal/scrapers/fetch"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const apiURL = "https://www.athletic.net/api/v1"
+
+func init() {
+	scrapers.Register("athnet", func(config scrapers.Config) (scrapers.Source, error) {
+		athletes, err := parseAthleteOptions(config.Options["athletes"])
+		if err != nil {
+			return nil, err
+		}
+		return NewSource(Config{
+			Backfill:  config.Backfill,
+			Transport: config.Transport,
+			API:       config.Options["api"],
+			Meets:     splitOption(config.Options["meets"]),
+			Teams:     splitOption(config.Options["teams"]),
+			Athletes:  athletes,
+		}), nil
+	})
+}
+
+type Config struct {
+	// Ingest the results history of each newly found athlete
+	Backfill bool
+	// Shared client every request is fetched through. One with the default limits is made if nil
+	Transport *fetch.Transport
+	// Base url of the api, athletic.net's if empty
+	API string
+	// Meets to scrape, as sport/meet ID such as xc/219417
+	Meets []string
+	// Teams whose meets this season are scraped once their results are posted, by athletic.net team ID
+	Teams []string
+	// Athletes whose results histories are scraped, by athletic.net athlete ID. An athlete mapped to a non-zero
+	// bactic ID is linked to that athlete when first found instead of being created, so that the high school results
+	// of athletes we already track are stored under them
+	Athletes map[int]uint32
+}
+
+// The transport of the config, or a new one with the default limits if none was given
+func (c Config) transport() *fetch.Transport {
+	if c.Transport == nil {
+		return fetch.NewTransport(fetch.DefaultConfig)
+	}
+	return c.Transport
+}
+
+// Comma-separated values of an option, none if it is empty
+func splitOption(option string) []string {
+	var values []string
+	for _, value := range strings.Split(option, ",") {
+		if value = strings.TrimSpace(value); len(value) > 0 {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// Parse the athletes option, a comma-separated list of athletic.net athlete IDs each optionally mapped to the bactic
+// athlete they are, as in 12345678=3054722741,23456789
+func parseAthleteOptions(option string) (map[int]uint32, error) {
+	athletes := make(map[int]uint32)
+	for _, value := range splitOption(option) {
+		athnetID, bacticID, linked := strings.Cut(value, "=")
+		id, err := strconv.Atoi(athnetID)
+		if err != nil {
+			return nil, fmt.Errorf("athlete %q is not an athletic.net athlete ID", value)
+		}
+		athletes[id] = 0
+		if linked {
+			link, err := strconv.ParseUint(bacticID, 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("athlete %q is not linked to a bactic athlete ID", value)
+			}
+			athletes[id] = uint32(link)
+		}
+	}
+	return athletes, nil
+}
+
+// Results source of athletic.net, where most high school cross country and track meets are posted. Athletes are
+// mapped to ours by their athletic.net IDs in the athlete source map, and schools by their team urls
+type Source struct {
+	config    Config
+	transport *fetch.Transport
+	client    *http.Client
+	logger    *log.Logger
+}
+
+func NewSource(config Config) *Source {
+	transport := config.transport()
+	if len(config.API) == 0 {
+		config.API = apiURL
+	}
+	config.API = strings.TrimSuffix(config.API, "/")
+	return &Source{
+		config:    config,
+		transport: transport,
+		client:    transport.Client(),
+		logger:    log.New(os.Stdout, "ATHNET ", log.Ldate|log.Ltime),
+	}
+}
+
+// Fetch a json document of the api into v
+func (s *Source) getJSON(ctx context.Context, url string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("fetching %s: %s", url, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// Meets given by the config, the meets of its teams this season with posted results, and the results histories of
+// its athletes. Calendars and histories that cannot be read are logged and skipped
+func (s *Source) Discover(ctx context.Context) ([]scrapers.MeetRef, error) {
+	var meets []scrapers.MeetRef
+	for _, option := range s.config.Meets {
+		meet, err := parseMeetOption(s.config.API, option)
+		if err != nil {
+			return nil, err
+		}
+		meets = append(meets, meet)
+	}
+
+	now := time.Now()
+	for _, team := range s.config.Teams {
+		for _, sport := range []string{sportXC, sportTF} {
+			var calendar []listedMeet
+			if err := s.getJSON(ctx, calendarURL(s.config.API, team, sport, calendarSeason(sport, now)), &calendar); err != nil {
+				s.logger.Printf("Unable to read the %s calendar of team %s: %v", sport, team, err)
+				continue
+			}
+			for _, listed := range calendar {
+				if !listed.ResultsPosted {
+					continue
+				}
+				meet, err := parseListedMeet(s.config.API, sport, listed)
+				if err != nil {
+					s.logger.Println("Skipping meet:", err)
+					continue
+				}
+				meets = append(meets, meet)
+			}
+		}
+	}
+
+	for athleteID := range s.config.Athletes {
+		history, err := s.athleteMeets(ctx, athleteID)
+		if err != nil {
+			s.logger.Printf("Unable to read the results history of athlete %d: %v", athleteID, err)
+			continue
+		}
+		// a history is queued like one found while parsing, so that its meets do not queue the histories of teammates
+		for i := range history {
+			history[i].Queued = true
+		}
+		meets = append(meets, history...)
+	}
+	return meets, ctx.Err()
+}
+
+// Meets listed in an athlete's results history
+func (s *Source) athleteMeets(ctx context.Context, athleteID int) ([]scrapers.MeetRef, error) {
+	var bio athleteBio
+	if err := s.getJSON(ctx, athleteURL(s.config.API, athleteID), &bio); err != nil {
+		return nil, err
+	}
+	var meets []scrapers.MeetRef
+	for _, listed := range bio.Meets {
+		meet, err := parseListedMeet(s.config.API, strings.ToLower(listed.Sport), listed)
+		if err != nil {
+			s.logger.Println("Skipping meet:", err)
+			continue
+		}
+		meets = append(meets, meet)
+	}
+	return meets, nil
+}
+
+func (s *Source) Fetch(ctx context.Context, meet scrapers.MeetRef) (scrapers.Page, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, meet.URL, nil)
+	if err != nil {
+		return scrapers.Page{}, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return scrapers.Page{}, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return scrapers.Page{}, err
+	}
+	if resp.StatusCode >= 400 {
+		return scrapers.Page{}, fmt.Errorf("fetching meet %s: %s", meet.URL, resp.Status)
+	}
+	return scrapers.Page{URL: meet.URL, Status: resp.StatusCode, Header: resp.Header, Body: body}, nil
+}
+
+// Results of an event of a meet, split into its heats in listed order
+type eventHeats struct {
+	event   eventInfo
+	heat    internal.Heat
+	heats   []int
+	results map[int][]resultInfo
+}
+
+// Group the results of a meet by event and heat. Several races of an event, such as varsity and junior varsity, are
+// numbered in listed order, while heats of a prelim round are numbered as listed and heats of a final are sections.
+// Events we have no type for, or that are run to a high school spec, are logged and skipped along with their results
+func groupResults(data meetData, sport string, logger *log.Logger) []*eventHeats {
+	var events []*eventHeats
+	byID := make(map[int]*eventHeats)
+	races := make(map[internal.Heat]int)
+	for _, event := range data.Events {
+		heat, err := parseEvent(event, sport)
+		if err != nil {
+			logger.Printf("Skipping event %d: %v", event.EventID, err)
+			continue
+		}
+		heat.Number = races[heat]
+		races[heat]++
+		e := &eventHeats{event: event, heat: heat, results: make(map[int][]resultInfo)}
+		events = append(events, e)
+		byID[event.EventID] = e
+	}
+
+	for _, result := range data.Results {
+		e, found := byID[result.EventID]
+		if !found {
+			continue
+		}
+		if _, found := e.results[result.Heat]; !found {
+			e.heats = append(e.heats, result.Heat)
+		}
+		e.results[result.Heat] = append(e.results[result.Heat], result)
+	}
+	return events
+}
+
+// The heat of an event that its results in heat number n are stored under
+func (e *eventHeats) heatOf(n int) internal.Heat {
+	heat := e.heat
+	if heat.Stage == internal.PRELIM && n > 0 {
+		heat.Number = n
+	} else {
+		heat.Section = n
+	}
+	return heat
+}
+
+// Parse a meet's results, creating the athletes and schools found in them. When backfilling, the results histories
+// of new athletes are returned
+func (s *Source) Parse(ctx context.Context, tx *sql.Tx, meetID uint32, ref scrapers.MeetRef, page scrapers.Page) ([]scrapers.MeetRef, error) {
+	sport, err := parseSport(ref.Key)
+	if err != nil {
+		return nil, err
+	}
+	var data meetData
+	if err = json.Unmarshal(page.Body, &data); err != nil {
+		return nil, fmt.Errorf("malformed results of meet %s: %w", ref.Key, err)
+	}
+	meet, err := parseMeet(data.Meet, meetID, ref)
+	if err != nil {
+		return nil, err
+	}
+	meet.Season = internal.XC
+	if sport == sportTF {
+		// track meets are only known to be indoor or outdoor once their events are read
+		meet.Season = internal.InferSeason(nil, meet.Date)
+	}
+	if err = database.InsertMeet(tx, meet); err != nil {
+		return nil, err
+	}
+
+	// only the meets a scrape starts from queue the histories of their new athletes, so that a backfill does not crawl
+	// on from teammate to teammate
+	p := parser{Source: s, ctx: ctx, tx: tx, backfill: s.config.Backfill && !ref.Queued, schools: make(map[int]uint32),
+		athletes: make(map[int]uint32)}
+	var eventTypes []internal.EventType
+	for _, event := range groupResults(data, sport, s.logger) {
+		eventTypes = append(eventTypes, event.heat.Type)
+		var firstHeatID uint32
+		for i, n := range event.heats {
+			var results []internal.Result
+			for _, listed := range event.results[n] {
+				result, err := parseResult(listed, event.heat.Type, sport)
+				if err != nil {
+					s.logger.Printf("Skipping result of %s in meet %s: %v", athleteName(listed.FirstName, listed.LastName), ref.Key, err)
+					continue
+				}
+				if err = p.resolve(&result, listed); err != nil {
+					return nil, err
+				}
+				results = append(results, result)
+			}
+
+			heat := event.heatOf(n)
+			heat.MeetID = meetID
+			heatID, err := database.InsertHeat(tx, heat, results)
+			if err != nil {
+				return nil, err
+			}
+			if i == 0 {
+				firstHeatID = heatID
+			}
+		}
+
+		var teams []internal.TeamResult
+		for _, score := range data.TeamScores {
+			if score.EventID != event.event.EventID || firstHeatID == 0 {
+				continue
+			}
+			schoolID, err := p.school(score.TeamID, score.SchoolName)
+			if err != nil {
+				return nil, err
+			}
+			if schoolID != 0 {
+				teams = append(teams, internal.TeamResult{SchoolID: schoolID, Place: score.Place, Score: score.Points})
+			}
+		}
+		if err = database.InsertTeamResults(tx, firstHeatID, teams); err != nil {
+			return nil, err
+		}
+	}
+	if sport == sportTF {
+		if err = database.SetMeetSeason(tx, meetID, internal.InferSeason(eventTypes, meet.Date)); err != nil {
+			return nil, err
+		}
+	}
+	return p.found, nil
+}
+
+// Resolves the athletes and schools of a meet's results, caching them by their athletic.net IDs
+type parser struct {
+	*Source
+	ctx      context.Context
+	tx       *sql.Tx
+	backfill bool
+	schools  map[int]uint32
+	athletes map[int]uint32
+	found    []scrapers.MeetRef
+}
+
+// Resolve the school of a result and its athlete or relay legs
+func (p *parser) resolve(result *internal.Result, listed resultInfo) error {
+	var err error
+	if result.SchoolID, err = p.school(listed.TeamID, listed.SchoolName); err != nil {
+		return err
+	}
+	if len(result.Team) > 0 || len(listed.Legs) > 0 {
+		for _, leg := range listed.Legs {
+			// legs listed without an athlete are left unlinked, rather than all taken for one athlete
+			if leg.AthleteID == 0 {
+				result.Members = append(result.Members, 0)
+				continue
+			}
+			athleteID, err := p.athlete(leg.AthleteID, athleteName(leg.FirstName, leg.LastName), result.SchoolID)
+			if err != nil {
+				return err
+			}
+			result.Members = append(result.Members, athleteID)
+		}
+		return nil
+	}
+	result.AthleteID, err = p.athlete(listed.AthleteID, athleteName(listed.FirstName, listed.LastName), result.SchoolID)
+	return err
+}
+
+// Resolve a team to its school, creating the school if it is new. Results without a team have no school
+func (p *parser) school(teamID int, name string) (uint32, error) {
+	if teamID == 0 {
+		return 0, nil
+	}
+	if schoolID, found := p.schools[teamID]; found {
+		return schoolID, nil
+	}
+	school, found := database.GetSchoolURL(p.tx, teamURL(teamID))
+	if !found {
+		// high schools have no collegiate division
+		school = internal.School{ID: uuid.New().ID(), Name: strings.TrimSpace(name), Division: -1, URL: teamURL(teamID)}
+		if err := database.InsertSchool(p.tx, school); err != nil {
+			return 0, fmt.Errorf("could not create school %s: %w", school.Name, err)
+		}
+	}
+	p.schools[teamID] = school.ID
+	return school.ID, nil
+}
+
+// Resolve an athletic.net athlete to our athlete, linking them to the athlete the config maps them to or creating
+// them if they are new. When backfilling, the results histories of athletes found for the first time are queued
+func (p *parser) athlete(athnetID int, name string, schoolID uint32) (uint32, error) {
+	athleteID, found := p.athletes[athnetID]
+	if !found {
+		var err error
+		if athleteID, err = p.resolveAthlete(athnetID, name); err != nil {
+			return 0, err
+		}
+		p.athletes[athnetID] = athleteID
+	}
+	if schoolID != 0 {
+		if err := database.AddAthleteToSchool(p.tx, athleteID, schoolID); err != nil {
+			return 0, fmt.Errorf("could not create athlete school relation: %w", err)
+		}
+	}
+	return athleteID, nil
+}
+
+func (p *parser) resolveAthlete(athnetID int, name string) (uint32, error) {
+	key := fmt.Sprintf("athnet/%d", athnetID)
+	if athleteID, found := database.GetAthleteSourceRelation(p.tx, key); found {
+		return athleteID, nil
+	}
+
+	athleteID := p.config.Athletes[athnetID]
+	if _, found := database.GetAthlete(p.tx, athleteID); athleteID == 0 || !found {
+		if athleteID != 0 {
+			p.logger.Printf("Athlete %d is linked to athlete %d, which is not stored, so %s is created", athnetID, athleteID, name)
+		}
+		athleteID = uuid.New().ID()
+		if err := database.InsertAthlete(p.tx, internal.Athlete{ID: athleteID, Name: name}); err != nil {
+			return 0, fmt.Errorf("could not create athlete %s: %w", name, err)
+		}
+	}
+	if err := database.AddAthleteSourceRelation(p.tx, key, athleteID); err != nil {
+		return 0, err
+	}
+
+	if p.backfill {
+		history, err := p.athleteMeets(p.ctx, athnetID)
+		if err != nil {
+			// an athlete whose history cannot be read is still stored with this meet
+			p.logger.Printf("Unable to read the results history of %s: %v", name, err)
+		}
+		p.found = append(p.found, history...)
+	}
+	return athleteID, nil
+}
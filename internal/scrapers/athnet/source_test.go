@@ -0,0 +1,81 @@
+package athnet
+
+import (
+	"bactic/internal/scrapers/fetch"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+)
+
+func TestParseAthleteOptions(t *testing.T) {
+	athletes, err := parseAthleteOptions("18001001=3054722741, 18001002")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(athletes) != 2 || athletes[18001001] != 3054722741 || athletes[18001002] != 0 {
+		t.Errorf("Unexpected athletes %v", athletes)
+	}
+	if athletes, err = parseAthleteOptions(""); err != nil || len(athletes) != 0 {
+		t.Errorf("Expected no athletes but got %v, %v", athletes, err)
+	}
+	for _, option := range []string{"ortiz", "18001001=ortiz"} {
+		if _, err = parseAthleteOptions(option); err == nil {
+			t.Errorf("Expected an error for athletes %q", option)
+		}
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/TeamHome/GetCalendar" && r.URL.Query().Get("sport") == sportXC:
+			http.ServeFile(w, r, "../../../test/athnet/calendar_xc.json")
+		case r.URL.Path == "/AthleteBio/GetAthleteBioData":
+			http.ServeFile(w, r, "../../../test/athnet/athlete_bio.json")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	source := NewSource(Config{
+		API:       server.URL,
+		Transport: fetch.NewTransport(fetch.Config{IgnoreRobots: true}),
+		Meets:     []string{"tf/500001"},
+		Teams:     []string{"1501"},
+		Athletes:  map[int]uint32{18001001: 0},
+	})
+	meets, err := source.Discover(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var keys []string
+	for _, meet := range meets {
+		keys = append(keys, meet.Key)
+	}
+	sort.Strings(keys)
+	// meets without posted results, in sports we do not scrape, or whose calendars cannot be read are skipped
+	expected := []string{"athnet/tf/500001", "athnet/tf/502311", "athnet/xc/219417", "athnet/xc/219417"}
+	if len(keys) != len(expected) {
+		t.Fatalf("Expected meets %v but got %v", expected, keys)
+	}
+	for i := range keys {
+		if keys[i] != expected[i] {
+			t.Errorf("Expected meets %v but got %v", expected, keys)
+			break
+		}
+	}
+	for _, meet := range meets {
+		if meet.Key == "athnet/xc/219417" && (meet.Name != "Clovis Invitational" || meet.Date.IsZero() || meet.URL != server.URL+"/Meet/GetResultsData?meetId=219417&sport=xc") {
+			t.Errorf("Unexpected meet %+v", meet)
+		}
+	}
+
+	source = NewSource(Config{API: server.URL, Meets: []string{"road/1"}})
+	if _, err = source.Discover(context.Background()); err == nil {
+		t.Error("Expected an error for a meet of an unknown sport")
+	}
+}
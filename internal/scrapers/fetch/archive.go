@@ -0,0 +1,170 @@
+package fetch
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// A fetch of a page held in the archive. Bodies are stored once by their digest, however many times they are fetched
+type Record struct {
+	URL     string
+	Fetched time.Time
+	Status  int
+	Header  http.Header
+	// Hex SHA-256 of the body
+	Digest string
+}
+
+// On-disk archive of every page fetched, so that pages can be parsed again without fetching them. Records are
+// appended to an index, and bodies are gzipped under the first two characters of their digest
+type Archive struct {
+	dir string
+	mu  sync.Mutex
+}
+
+const archiveIndex = "index.jsonl"
+
+// Open the archive in dir, creating it if it does not exist
+func OpenArchive(dir string) (*Archive, error) {
+	if err := os.MkdirAll(filepath.Join(dir, "bodies"), 0o755); err != nil {
+		return nil, err
+	}
+	return &Archive{dir: dir}, nil
+}
+
+func (a *Archive) bodyPath(digest string) string {
+	return filepath.Join(a.dir, "bodies", digest[:2], digest+".gz")
+}
+
+// Store a fetched page, writing its body unless a page with the same content was stored before
+func (a *Archive) Store(url string, status int, header http.Header, body []byte) (Record, error) {
+	sum := sha256.Sum256(body)
+	record := Record{
+		URL:     url,
+		Fetched: time.Now().UTC(),
+		Status:  status,
+		Header:  header,
+		Digest:  hex.EncodeToString(sum[:]),
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	path := a.bodyPath(record.Digest)
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		if err := writeBody(path, body); err != nil {
+			return Record{}, err
+		}
+	} else if err != nil {
+		return Record{}, err
+	}
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		return Record{}, err
+	}
+	index, err := os.OpenFile(filepath.Join(a.dir, archiveIndex), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return Record{}, err
+	}
+	defer index.Close()
+	if _, err = index.Write(append(line, '\n')); err != nil {
+		return Record{}, err
+	}
+	return record, nil
+}
+
+// Write a body to a temporary file first, so that an interrupted write never leaves a partial body under its digest
+func writeBody(path string, body []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "body-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	zw := gzip.NewWriter(tmp)
+	if _, err = zw.Write(body); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = zw.Close(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Every record in the archive, in the order they were fetched
+func (a *Archive) Records() ([]Record, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	index, err := os.Open(filepath.Join(a.dir, archiveIndex))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer index.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(index)
+	// header lines of a record can be long
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("malformed archive record: %w", err)
+		}
+		records = append(records, record)
+	}
+	return records, scanner.Err()
+}
+
+// Body of a record in the archive
+func (a *Archive) Body(digest string) ([]byte, error) {
+	if len(digest) < 2 {
+		return nil, fmt.Errorf("malformed digest %q", digest)
+	}
+	f, err := os.Open(a.bodyPath(digest))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return io.ReadAll(zr)
+}
+
+// Store a response in the archive, replacing its body with the bytes read so that it can still be parsed
+func (a *Archive) storeResponse(resp *http.Response) error {
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	_, err = a.Store(resp.Request.URL.String(), resp.StatusCode, resp.Header, body)
+	return err
+}
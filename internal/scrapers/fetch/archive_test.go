@@ -0,0 +1,115 @@
+package fetch_test
+
+import (
+	"bactic/internal/scrapers/fetch"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestArchive(t *testing.T) {
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<h3>Bactic Championships</h3>"))
+	})
+	dir := t.TempDir()
+	archive, err := fetch.OpenArchive(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transport := fetch.NewTransport(fetch.Config{IgnoreRobots: true, Archive: archive})
+
+	for _, path := range []string{"/results/1", "/results/2"} {
+		resp, err := transport.Client().Get(server.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(body) != "<h3>Bactic Championships</h3>" {
+			t.Errorf("Expected the archived response to still be readable but got %q", body)
+		}
+	}
+
+	records, err := archive.Records()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 records but got %d", len(records))
+	}
+	if records[0].URL != server.URL+"/results/1" || records[0].Status != http.StatusOK || records[0].Header.Get("Content-Type") != "text/html" {
+		t.Errorf("Unexpected record %+v", records[0])
+	}
+	if records[0].Digest != records[1].Digest {
+		t.Error("Expected pages with the same content to share a digest")
+	}
+	bodies, err := filepath.Glob(filepath.Join(dir, "bodies", "*", "*.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bodies) != 1 {
+		t.Errorf("Expected a single stored body but got %d", len(bodies))
+	}
+
+	body, err := archive.Body(records[1].Digest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != "<h3>Bactic Championships</h3>" {
+		t.Errorf("Unexpected archived body %q", body)
+	}
+
+	// records persist across openings of the archive
+	reopened, err := fetch.OpenArchive(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if records, err = reopened.Records(); err != nil || len(records) != 2 {
+		t.Errorf("Expected 2 records after reopening but got %d: %v", len(records), err)
+	}
+	if _, err = os.Stat(filepath.Join(dir, "index.jsonl")); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<h3>" + r.URL.Path + "</h3>"))
+	})
+	archive, err := fetch.OpenArchive(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	live := fetch.NewTransport(fetch.Config{IgnoreRobots: true, Archive: archive})
+	resp, err := live.Client().Get(server.URL + "/results/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	server.Close()
+
+	replay := fetch.NewTransport(fetch.Config{Archive: archive, Replay: true})
+	resp, err = replay.Client().Get(server.URL + "/results/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || string(body) != "<h3>/results/1</h3>" {
+		t.Errorf("Expected the archived page but got status %d and body %q", resp.StatusCode, body)
+	}
+
+	if _, err = replay.Client().Get(server.URL + "/results/2"); !errors.Is(err, fetch.ErrNotArchived) {
+		t.Errorf("Expected a page missing from the archive to not be fetched but got %v", err)
+	}
+}
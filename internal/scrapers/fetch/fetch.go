@@ -0,0 +1,274 @@
+package fetch
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/temoto/robotstxt"
+)
+
+// Returned for requests to paths a site's robots.txt disallows us from fetching
+var ErrDisallowed = errors.New("disallowed by robots.txt")
+
+// Returned when replaying a request for a page that was never archived
+var ErrNotArchived = errors.New("page not archived")
+
+// Politeness of the requests made to each site
+type Config struct {
+	// Sent with every request, and matched against the groups of robots.txt
+	UserAgent string
+	// Requests in flight to a site at once
+	Concurrency int
+	// Least time between the start of two requests to a site
+	Delay time.Duration
+	// Times a request is retried when it fails or is answered with 429 or a 5xx status
+	Retries int
+	// Wait before the first retry, doubling with each retry after. A Retry-After header takes precedence
+	Backoff time.Duration
+	// Fetch pages regardless of robots.txt
+	IgnoreRobots bool
+	// Archive every fetched page is stored in, if not nil
+	Archive *Archive
+	// Serve every request from the archive instead of the network
+	Replay bool
+}
+
+var DefaultConfig = Config{
+	UserAgent:   "bactic-scraper/1.0",
+	Concurrency: 2,
+	Delay:       time.Second,
+	Retries:     3,
+	Backoff:     2 * time.Second,
+}
+
+// Longest a site's robots.txt is waited on
+const robotsTimeout = 30 * time.Second
+
+// Rate limit and robots.txt rules of a site
+type site struct {
+	slots chan struct{}
+	mu    sync.Mutex
+	next  time.Time
+	// held while robots.txt is read, so that only requests to the site wait on it
+	robotsMu   sync.Mutex
+	robots     *robotstxt.RobotsData
+	robotsRead bool
+	// earliest time a robots.txt that could not be read is read again
+	robotsRetry time.Time
+}
+
+// An http.RoundTripper that every request of a scraper goes through, so that limits are shared by its collectors and
+// direct fetches alike
+type Transport struct {
+	config Config
+	base   http.RoundTripper
+	mu     sync.Mutex
+	sites  map[string]*site
+	// latest record of each archived url, read once on the first replayed request
+	replayOnce sync.Once
+	replayed   map[string]Record
+	replayErr  error
+}
+
+func NewTransport(config Config) *Transport {
+	if config.Concurrency < 1 {
+		config.Concurrency = 1
+	}
+	return &Transport{
+		config: config,
+		base:   http.DefaultTransport,
+		sites:  make(map[string]*site),
+	}
+}
+
+// Archive pages are stored in or replayed from, nil if pages are not archived
+func (t *Transport) Archive() *Archive {
+	return t.config.Archive
+}
+
+// Client whose requests go through the transport
+func (t *Transport) Client() *http.Client {
+	return &http.Client{Transport: t}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.config.Replay {
+		return t.replay(req)
+	}
+
+	req = req.Clone(req.Context())
+	if len(t.config.UserAgent) > 0 {
+		req.Header.Set("User-Agent", t.config.UserAgent)
+	}
+
+	s := t.site(req.URL.Host)
+	if !t.config.IgnoreRobots {
+		if robots := t.robots(s, req); robots != nil && !robots.TestAgent(req.URL.Path, req.Header.Get("User-Agent")) {
+			return nil, fmt.Errorf("fetching %s: %w", req.URL, ErrDisallowed)
+		}
+	}
+
+	backoff := t.config.Backoff
+	for attempt := 0; ; attempt++ {
+		resp, err := t.send(req, s)
+		retry := err != nil || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		if !retry || attempt >= t.config.Retries || (req.Body != nil && req.GetBody == nil) {
+			if err == nil && t.config.Archive != nil {
+				if err = t.config.Archive.storeResponse(resp); err != nil {
+					resp.Body.Close()
+					return nil, fmt.Errorf("archiving %s: %w", req.URL, err)
+				}
+			}
+			return resp, err
+		}
+
+		wait := backoff
+		if err == nil {
+			if retryAfter, found := parseRetryAfter(resp.Header.Get("Retry-After")); found {
+				wait = retryAfter
+			}
+			resp.Body.Close()
+		}
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(wait):
+		}
+		backoff *= 2
+
+		if req.GetBody != nil {
+			if req.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// Parse a Retry-After header, given either as seconds to wait or as the date to retry at
+func parseRetryAfter(header string) (time.Duration, bool) {
+	if seconds, err := strconv.Atoi(header); err == nil {
+		return time.Duration(seconds) * time.Second, true
+	}
+	date, err := http.ParseTime(header)
+	if err != nil {
+		return 0, false
+	}
+	if wait := time.Until(date); wait > 0 {
+		return wait, true
+	}
+	return 0, true
+}
+
+// Answer a request with the latest archived fetch of its url
+func (t *Transport) replay(req *http.Request) (*http.Response, error) {
+	if t.config.Archive == nil {
+		return nil, fmt.Errorf("replaying %s: no archive to replay from", req.URL)
+	}
+	t.replayOnce.Do(func() {
+		var records []Record
+		records, t.replayErr = t.config.Archive.Records()
+		t.replayed = make(map[string]Record, len(records))
+		for _, record := range records {
+			t.replayed[record.URL] = record
+		}
+	})
+	if t.replayErr != nil {
+		return nil, t.replayErr
+	}
+
+	record, found := t.replayed[req.URL.String()]
+	if !found {
+		return nil, fmt.Errorf("replaying %s: %w", req.URL, ErrNotArchived)
+	}
+	body, err := t.config.Archive.Body(record.Digest)
+	if err != nil {
+		return nil, fmt.Errorf("replaying %s: %w", req.URL, err)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", record.Status, http.StatusText(record.Status)),
+		StatusCode:    record.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        record.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// Send a request once a slot to its site is free and the delay since the last request has passed
+func (t *Transport) send(req *http.Request, s *site) (*http.Response, error) {
+	select {
+	case s.slots <- struct{}{}:
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+	defer func() { <-s.slots }()
+
+	s.mu.Lock()
+	wait := time.Until(s.next)
+	if wait < 0 {
+		wait = 0
+	}
+	s.next = time.Now().Add(wait + t.config.Delay)
+	s.mu.Unlock()
+
+	select {
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	case <-time.After(wait):
+	}
+	return t.base.RoundTrip(req)
+}
+
+// Get the limits of a site, adding them the first time it is seen
+func (t *Transport) site(host string) *site {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s, found := t.sites[host]
+	if !found {
+		s = &site{slots: make(chan struct{}, t.config.Concurrency)}
+		t.sites[host] = s
+	}
+	return s
+}
+
+// Get the robots.txt rules of the request's site, reading them on the first request to it. A robots.txt that cannot be
+// read is taken to allow everything, and is read again on a later request once the backoff has passed
+func (t *Transport) robots(s *site, req *http.Request) *robotstxt.RobotsData {
+	s.robotsMu.Lock()
+	defer s.robotsMu.Unlock()
+	if s.robotsRead || time.Now().Before(s.robotsRetry) {
+		return s.robots
+	}
+
+	// robots.txt is read with a context of its own, so that a cancelled request does not decide the rules of the site
+	ctx, cancel := context.WithTimeout(context.Background(), robotsTimeout)
+	defer cancel()
+	robotsReq, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s://%s/robots.txt", req.URL.Scheme, req.URL.Host), nil)
+	if err != nil {
+		return nil
+	}
+	robotsReq.Header.Set("User-Agent", req.Header.Get("User-Agent"))
+	if resp, err := t.send(robotsReq, s); err == nil {
+		// a missing robots.txt allows everything, while a server error is only passing
+		if resp.StatusCode < 500 {
+			s.robots, _ = robotstxt.FromResponse(resp)
+			s.robotsRead = true
+		}
+		resp.Body.Close()
+	}
+	if !s.robotsRead {
+		s.robotsRetry = time.Now().Add(t.config.Backoff)
+	}
+	return s.robots
+}
@@ -0,0 +1,159 @@
+package fetch_test
+
+import (
+	"bactic/internal/scrapers/fetch"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newServer(t *testing.T, handler http.HandlerFunc) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("User-agent: *\nDisallow: /private\n"))
+	})
+	mux.HandleFunc("/", handler)
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestRetry(t *testing.T) {
+	var requests atomic.Int32
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(r.Header.Get("User-Agent")))
+	})
+	transport := fetch.NewTransport(fetch.Config{UserAgent: "bactic-test", Retries: 2, Backoff: time.Millisecond})
+
+	resp, err := transport.Client().Get(server.URL + "/results")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || requests.Load() != 3 {
+		t.Errorf("Expected a success on the third request but got status %d after %d requests", resp.StatusCode, requests.Load())
+	}
+
+	requests.Store(-10)
+	resp, err = transport.Client().Get(server.URL + "/results")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Expected the last failure to be returned once retries ran out but got status %d", resp.StatusCode)
+	}
+}
+
+func TestRetryAfterDate(t *testing.T) {
+	var requests atomic.Int32
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			// dates are given to the second, so this asks for a wait of at least a second
+			w.Header().Set("Retry-After", time.Now().Add(2*time.Second).UTC().Format(http.TimeFormat))
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	})
+	transport := fetch.NewTransport(fetch.Config{Retries: 1, Backoff: time.Millisecond})
+
+	start := time.Now()
+	resp, err := transport.Client().Get(server.URL + "/results")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || time.Since(start) < time.Second {
+		t.Errorf("Expected a success after waiting until the retry date but got status %d after %v", resp.StatusCode, time.Since(start))
+	}
+}
+
+func TestRobots(t *testing.T) {
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {})
+	transport := fetch.NewTransport(fetch.Config{UserAgent: "bactic-test"})
+
+	if _, err := transport.Client().Get(server.URL + "/private/page"); !errors.Is(err, fetch.ErrDisallowed) {
+		t.Errorf("Expected a disallowed path to not be fetched but got %v", err)
+	}
+	resp, err := transport.Client().Get(server.URL + "/public/page")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	ignoring := fetch.NewTransport(fetch.Config{UserAgent: "bactic-test", IgnoreRobots: true})
+	resp, err = ignoring.Client().Get(server.URL + "/private/page")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+}
+
+func TestRobotsRetry(t *testing.T) {
+	// a request cancelled before it is sent does not leave the site without rules
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {})
+	transport := fetch.NewTransport(fetch.Config{UserAgent: "bactic-test"})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/public/page", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = transport.Client().Do(req); err == nil {
+		t.Error("Expected a cancelled request to fail")
+	}
+	if _, err = transport.Client().Get(server.URL + "/private/page"); !errors.Is(err, fetch.ErrDisallowed) {
+		t.Errorf("Expected the rules to be read despite the cancelled request but got %v", err)
+	}
+
+	// a robots.txt that could not be read is read again
+	var robotsRequests atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, r *http.Request) {
+		if robotsRequests.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("User-agent: *\nDisallow: /private\n"))
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {})
+	flaky := httptest.NewServer(mux)
+	defer flaky.Close()
+
+	resp, err := transport.Client().Get(flaky.URL + "/private/page")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if _, err = transport.Client().Get(flaky.URL + "/private/page"); !errors.Is(err, fetch.ErrDisallowed) {
+		t.Errorf("Expected robots.txt to be read again but got %v", err)
+	}
+	if robotsRequests.Load() != 2 {
+		t.Errorf("Expected robots.txt to be read twice but it was read %d times", robotsRequests.Load())
+	}
+}
+
+func TestDelay(t *testing.T) {
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {})
+	delay := 50 * time.Millisecond
+	transport := fetch.NewTransport(fetch.Config{Delay: delay, IgnoreRobots: true})
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		resp, err := transport.Client().Get(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	if elapsed := time.Since(start); elapsed < 2*delay {
+		t.Errorf("Expected 3 requests to take at least %v but took %v", 2*delay, elapsed)
+	}
+}
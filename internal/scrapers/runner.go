@@ -0,0 +1,112 @@
+package scrapers
+
+import (
+	"bactic/internal/database"
+	"context"
+	"database/sql"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Scrape a meet in its own transaction, returning the meets found while parsing it. A meet already in the meet map is
+// skipped unless it was not queued and refresh is set or it is recent, in which case it is parsed again in place and
+// results its page no longer lists are removed. Nothing is stored if the context is cancelled before the meet is parsed
+func Scrape(db *sql.DB, ctx context.Context, source Source, meet MeetRef, refresh bool, logger *log.Logger) ([]MeetRef, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	meetID, seen := database.GetMeetRelation(tx, meet.Key)
+	if seen && (meet.Queued || !(refresh || meet.Recent(time.Now()))) {
+		return nil, tx.Rollback()
+	} else if !seen {
+		meetID = uuid.New().ID()
+	}
+
+	found, err := scrapeInTx(tx, ctx, source, meetID, seen, meet, logger)
+	if err == nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return nil, rollbackErr
+		}
+		return nil, err
+	}
+	return found, tx.Commit()
+}
+
+func scrapeInTx(tx *sql.Tx, ctx context.Context, source Source, meetID uint32, seen bool, meet MeetRef, logger *log.Logger) ([]MeetRef, error) {
+	page, err := source.Fetch(ctx, meet)
+	if err != nil {
+		return nil, err
+	}
+	found, err := source.Parse(ctx, tx, meetID, meet, page)
+	if err != nil {
+		return nil, err
+	}
+
+	if !seen {
+		return found, database.AddMeetRelation(tx, meet.Key, meetID)
+	}
+	// corrections posted since the meet was last scraped have been applied, so drop results the page no longer lists
+	removed, err := database.RemoveStaleResults(tx, meetID)
+	if removed > 0 {
+		logger.Printf("Removed %d results no longer listed for meet %s", removed, meet.Key)
+	}
+	return found, err
+}
+
+// Scrape meets in order, followed by the meets found while parsing them, which are marked as queued so that they do not
+// queue meets in turn. Meets that fail are logged and skipped, and the number that failed is returned
+func ScrapeAll(db *sql.DB, ctx context.Context, source Source, meets []MeetRef, refresh bool, logger *log.Logger) int {
+	queued := make(map[string]bool, len(meets))
+	for _, meet := range meets {
+		queued[meet.Key] = true
+	}
+	failed := 0
+	for len(meets) > 0 && ctx.Err() == nil {
+		meet := meets[0]
+		meets = meets[1:]
+		found, err := Scrape(db, ctx, source, meet, refresh, logger)
+		if err != nil {
+			logger.Printf("Unable to scrape meet %s, skipping: %v", meet.Key, err)
+			failed++
+			continue
+		}
+		for _, next := range found {
+			if !queued[next.Key] {
+				queued[next.Key] = true
+				next.Queued = true
+				meets = append(meets, next)
+			}
+		}
+	}
+	return failed
+}
+
+// Scrape the meets a source discovers every interval until the context is cancelled
+func Run(db *sql.DB, ctx context.Context, wg *sync.WaitGroup, name string, source Source, interval time.Duration, refresh bool) {
+	defer wg.Done()
+	logger := log.New(log.Writer(), name+" ", log.Ldate|log.Ltime)
+
+	// the first scrape starts immediately, and a scrape in progress finishes its current meet when cancelled
+	scrapeTimer := time.NewTimer(0)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-scrapeTimer.C:
+			scrapeTimer.Reset(interval)
+			meets, err := source.Discover(ctx)
+			if err != nil {
+				logger.Println("Unable to discover meets:", err)
+				continue
+			}
+			ScrapeAll(db, ctx, source, meets, refresh, logger)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package scrapers
+
+import (
+	"bactic/internal/scrapers/fetch"
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// A meet listed by a source
+type MeetRef struct {
+	// Identifies the meet in the meet map across scrapes, and must be unique across sources, such as tfrrs/tf/79700
+	Key  string
+	Name string
+	URL  string
+	// Dates listed alongside the meet, zero if they are only known from its page
+	Date    time.Time
+	EndDate time.Time
+	// Found while parsing another meet, such as in a new athlete's history. Queued meets do not queue meets of their
+	// own, and are not scraped again
+	Queued bool
+}
+
+// Results are often corrected in the days after a meet, so meets that ended within the window are scraped again on
+// every pass
+const CorrectionWindow = 14 * 24 * time.Hour
+
+// Whether the meet ended within the correction window of now. Meets without a listed date are never recent
+func (m MeetRef) Recent(now time.Time) bool {
+	end := m.EndDate
+	if end.IsZero() {
+		end = m.Date
+	}
+	return !end.IsZero() && !end.After(now) && now.Sub(end) <= CorrectionWindow
+}
+
+// A fetched page of a meet
+type Page struct {
+	URL    string
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// A provider of meet results. Meets are discovered, then each is fetched and parsed into the database
+type Source interface {
+	// Meets currently listed by the source, such as in its feed of recent results
+	Discover(ctx context.Context) ([]MeetRef, error)
+	// Fetch the page of a meet
+	Fetch(ctx context.Context, meet MeetRef) (Page, error)
+	// Parse a fetched meet into the database under meetID, inserting the meet itself. Returns meets found while
+	// parsing that should be scraped next, such as those in the histories of new athletes, unless the meet was queued
+	Parse(ctx context.Context, tx *sql.Tx, meetID uint32, meet MeetRef, page Page) ([]MeetRef, error)
+}
+
+// Options a source is created with
+type Config struct {
+	// Shared client every request is fetched through
+	Transport *fetch.Transport
+	// Scrape meets that were already stored again, updating them in place
+	Refresh bool
+	// Scrape the meets found while parsing others, such as the full results history of each new athlete
+	Backfill bool
+	// Options only understood by the source, by name
+	Options map[string]string
+}
+
+// Creates a source from its config
+type Factory func(config Config) (Source, error)
+
+var (
+	registryMu sync.Mutex
+	registry   = make(map[string]Factory)
+)
+
+// Register a source under a name it can be selected by. Sources register themselves when their package is imported,
+// and registering a name twice panics
+func Register(name string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, found := registry[name]; found {
+		panic("scrapers: source registered twice: " + name)
+	}
+	registry[name] = factory
+}
+
+// Names of the registered sources, sorted
+func Sources() []string {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Create the source registered under name
+func New(name string, config Config) (Source, error) {
+	registryMu.Lock()
+	factory, found := registry[name]
+	registryMu.Unlock()
+	if !found {
+		return nil, fmt.Errorf("no source registered as %s", name)
+	}
+	return factory(config)
+}
@@ -0,0 +1,78 @@
+package scrapers_test
+
+import (
+	"bactic/internal/scrapers"
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+)
+
+type fakeSource struct {
+	config scrapers.Config
+}
+
+func (s *fakeSource) Discover(ctx context.Context) ([]scrapers.MeetRef, error) {
+	return []scrapers.MeetRef{{Key: "fake/1"}}, nil
+}
+
+func (s *fakeSource) Fetch(ctx context.Context, meet scrapers.MeetRef) (scrapers.Page, error) {
+	return scrapers.Page{URL: meet.URL}, nil
+}
+
+func (s *fakeSource) Parse(ctx context.Context, tx *sql.Tx, meetID uint32, meet scrapers.MeetRef, page scrapers.Page) ([]scrapers.MeetRef, error) {
+	return nil, nil
+}
+
+func TestRegister(t *testing.T) {
+	scrapers.Register("fake", func(config scrapers.Config) (scrapers.Source, error) {
+		return &fakeSource{config: config}, nil
+	})
+
+	found := false
+	for _, name := range scrapers.Sources() {
+		found = found || name == "fake"
+	}
+	if !found {
+		t.Errorf("Expected the fake source to be listed but got %v", scrapers.Sources())
+	}
+
+	source, err := scrapers.New("fake", scrapers.Config{Refresh: true, Options: map[string]string{"feed": "url"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config := source.(*fakeSource).config; !config.Refresh || config.Options["feed"] != "url" {
+		t.Errorf("Expected the source to be created with its config but got %+v", config)
+	}
+	if _, err = scrapers.New("missing", scrapers.Config{}); err == nil {
+		t.Error("Expected an error for a source that was never registered")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected registering a name twice to panic")
+		}
+	}()
+	scrapers.Register("fake", func(config scrapers.Config) (scrapers.Source, error) { return nil, nil })
+}
+
+func TestRecent(t *testing.T) {
+	now := time.Date(2024, time.April, 20, 12, 0, 0, 0, time.UTC)
+	day := func(month time.Month, d int) time.Time { return time.Date(2024, month, d, 0, 0, 0, 0, time.UTC) }
+	tests := []struct {
+		meet   scrapers.MeetRef
+		recent bool
+	}{
+		{scrapers.MeetRef{Date: day(time.April, 13)}, true},
+		{scrapers.MeetRef{Date: day(time.March, 30)}, false},
+		// a multi-day meet is recent from when it ended
+		{scrapers.MeetRef{Date: day(time.April, 2), EndDate: day(time.April, 10)}, true},
+		{scrapers.MeetRef{Date: day(time.May, 4)}, false},
+		{scrapers.MeetRef{}, false},
+	}
+	for _, test := range tests {
+		if recent := test.meet.Recent(now); recent != test.recent {
+			t.Errorf("Expected a meet from %v to %v to be recent %v", test.meet.Date, test.meet.EndDate, test.recent)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package tfrrs
+
+import (
+	"bactic/internal"
+	"bactic/internal/database"
+	"bactic/internal/scrapers"
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/gocolly/colly"
+)
+
+// Meets to backfill, either listed between two dates or given by their tfrrs IDs
+type BackfillOptions struct {
+	From time.Time
+	To   time.Time
+	// Only backfill meets of this season, or every season if negative. Track meets are placed indoor or outdoor by date
+	Season int
+	// Meet IDs as they appear in result links, such as 79700 for a track meet or xc/23218 for cross country
+	MeetIDs []string
+}
+
+// Sports listed on the tfrrs result search, by the seasons they hold
+var listingSports = map[string][]int{
+	"xc":    {internal.XC},
+	"track": {internal.INDOOR, internal.OUTDOOR},
+}
+
+// Result search page of tfrrs listing the meets of a sport held in a month
+func listingURL(sport string, month time.Time, page int) string {
+	return fmt.Sprintf("https://www.tfrrs.org/results_search.html?sport=%s&year=%d&month=%d&page=%d", sport, month.Year(), month.Month(), page)
+}
+
+// Scrape the meets of the backfill, skipping meets already stored. Each month of listings is recorded once scraped in
+// full, so an interrupted backfill resumes from the month it stopped in
+func Backfill(db *sql.DB, ctx context.Context, options BackfillOptions, config Config) {
+	logger := log.New(os.Stdout, "Backfill ", log.Ldate|log.Ltime)
+	config.Transport = config.transport()
+	source := NewSource(config)
+	scrape := func(meet scrapers.MeetRef) bool {
+		return scrapers.ScrapeAll(db, ctx, source, []scrapers.MeetRef{meet}, config.Refresh, logger) == 0
+	}
+
+	// meets given by ID have their names and dates read from their page header
+	for _, id := range options.MeetIDs {
+		if ctx.Err() != nil {
+			return
+		}
+		url := "https://www.tfrrs.org/results/" + strings.Trim(id, "/")
+		key, err := parseMeetKey(url)
+		if err != nil {
+			logger.Printf("Unable to identify meet %s, skipping: %v", id, err)
+			continue
+		}
+		scrape(scrapers.MeetRef{Key: key, URL: url})
+	}
+
+	if options.From.IsZero() || options.To.IsZero() {
+		return
+	}
+	for month := time.Date(options.From.Year(), options.From.Month(), 1, 0, 0, 0, 0, time.UTC); !month.After(options.To); month = month.AddDate(0, 1, 0) {
+		for sport, seasons := range listingSports {
+			if options.Season >= 0 && !containsSeason(seasons, options.Season) {
+				continue
+			}
+			progressKey := fmt.Sprintf("tfrrs/listing/%s/%s", sport, month.Format("2006-01"))
+			if backfillDone(db, progressKey) {
+				logger.Printf("Skipping %s listings of %s, already backfilled", sport, month.Format("January 2006"))
+				continue
+			}
+
+			links, err := listMeets(config.Transport, sport, month, logger)
+			if err != nil {
+				logger.Printf("Unable to list %s meets of %s, leaving it to be resumed: %v", sport, month.Format("January 2006"), err)
+				continue
+			}
+			complete := true
+			for _, link := range links {
+				if ctx.Err() != nil {
+					return
+				}
+				if link.Date.Before(options.From) || link.Date.After(options.To) {
+					continue
+				}
+				if options.Season >= 0 && parseSeason(link.URL, link.Date, nil) != options.Season {
+					continue
+				}
+				if !scrape(link) {
+					complete = false
+				}
+			}
+
+			if ctx.Err() != nil {
+				return
+			}
+			// a month is only recorded once every meet listed in it was scraped, so failed meets are retried on resume
+			if !complete {
+				logger.Printf("Some %s meets of %s could not be scraped, leaving it to be resumed", sport, month.Format("January 2006"))
+				continue
+			}
+			if !monthCovered(month, options, time.Now()) {
+				continue
+			}
+			tx, err := db.Begin()
+			if err != nil {
+				panic(err)
+			}
+			if err = database.SetBackfillProgress(tx, progressKey); err != nil {
+				panic(err)
+			}
+			if err = tx.Commit(); err != nil {
+				panic(err)
+			}
+		}
+	}
+}
+
+// Whether the backfill scraped every meet of a month, so that it can be recorded. Months that are only partly in the
+// range, or not over yet, and backfills of a single season leave meets of the month to a later backfill
+func monthCovered(month time.Time, options BackfillOptions, now time.Time) bool {
+	next := month.AddDate(0, 1, 0)
+	return options.Season < 0 && !month.Before(options.From) && !next.AddDate(0, 0, -1).After(options.To) && !now.Before(next)
+}
+
+func containsSeason(seasons []int, season int) bool {
+	for _, s := range seasons {
+		if s == season {
+			return true
+		}
+	}
+	return false
+}
+
+func backfillDone(db *sql.DB, key string) bool {
+	tx, err := db.Begin()
+	if err != nil {
+		panic(err)
+	}
+	defer tx.Rollback()
+	return database.GetBackfillProgress(tx, key)
+}
+
+// Walk the pages of the result search for a sport and month, returning the meets listed. Fails if any page could not
+// be read, so that a month is never taken to be fully listed when it is not
+func listMeets(transport http.RoundTripper, sport string, month time.Time, logger *log.Logger) ([]scrapers.MeetRef, error) {
+	var (
+		links    []scrapers.MeetRef
+		previous string
+	)
+	listingCollector := colly.NewCollector()
+	listingCollector.WithTransport(transport)
+	for page := 1; ; page++ {
+		var rows [][][]string
+		listingCollector.OnHTML("table tbody tr", func(h *colly.HTMLElement) {
+			var row [][]string
+			h.DOM.Children().Each(func(_ int, cell *goquery.Selection) {
+				item := []string{strings.TrimSpace(cell.Text())}
+				cell.Find("a").Each(func(_ int, a *goquery.Selection) {
+					if href, found := a.Attr("href"); found {
+						item = append(item, h.Request.AbsoluteURL(href))
+					}
+				})
+				row = append(row, item)
+			})
+			rows = append(rows, row)
+		})
+		err := listingCollector.Visit(listingURL(sport, month, page))
+		listingCollector.OnHTMLDetach("table tbody tr")
+		if err != nil {
+			return nil, fmt.Errorf("visiting page %d: %w", page, err)
+		}
+
+		// a site that ignores the page parameter serves the same page forever
+		listed := fmt.Sprint(rows)
+		if len(rows) == 0 || listed == previous {
+			return links, nil
+		}
+		previous = listed
+		for _, row := range rows {
+			link, err := parseListingRow(row)
+			if err != nil {
+				logger.Println("Unable to parse meet listing, skipping:", err)
+				continue
+			}
+			links = append(links, link)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package tfrrs
+
+import (
+	"bactic/internal"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+const listingPage = `<table><tbody><tr>
+<td>10/28/23</td>
+<td><a href="/results/xc/23218/2023_SCIAC_Cross_Country_Championships">2023 SCIAC Cross Country Championships</a></td>
+<td><a href="/teams/xc/CA_college_m_Pomona_Pitzer.html">Pomona-Pitzer</a></td>
+<td>CA</td>
+</tr></tbody></table>`
+
+func servePage(req *http.Request, status int, body string) *http.Response {
+	return &http.Response{
+		StatusCode: status,
+		Header:     http.Header{"Content-Type": {"text/html"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}
+}
+
+func TestListMeets(t *testing.T) {
+	logger := log.New(io.Discard, "", 0)
+	month := time.Date(2023, time.October, 1, 0, 0, 0, 0, time.UTC)
+
+	// a site that ignores the page parameter serves the first page forever
+	var visits int
+	repeating := roundTripper(func(req *http.Request) (*http.Response, error) {
+		if visits++; visits > 10 {
+			t.Fatal("Expected listing to stop once a page repeats")
+		}
+		return servePage(req, http.StatusOK, listingPage), nil
+	})
+	links, err := listMeets(repeating, "xc", month, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(links) != 1 || links[0].Key != "tfrrs/xc/23218" || visits != 2 {
+		t.Errorf("Expected the single listed meet after 2 visits but got %+v after %d", links, visits)
+	}
+
+	// a page that cannot be read fails the month rather than cutting its listings short
+	failing := roundTripper(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Query().Get("page") == "2" {
+			return nil, errors.New("connection reset")
+		}
+		return servePage(req, http.StatusOK, listingPage), nil
+	})
+	if _, err = listMeets(failing, "xc", month, logger); err == nil {
+		t.Error("Expected an error when a listing page cannot be read")
+	}
+}
+
+func TestMonthCovered(t *testing.T) {
+	march := time.Date(2023, time.March, 1, 0, 0, 0, 0, time.UTC)
+	now := time.Date(2024, time.January, 10, 0, 0, 0, 0, time.UTC)
+	months := []struct {
+		month   time.Time
+		options BackfillOptions
+		covered bool
+	}{
+		{march, BackfillOptions{From: march, To: time.Date(2023, time.March, 31, 0, 0, 0, 0, time.UTC), Season: -1}, true},
+		{march, BackfillOptions{From: time.Date(2023, time.March, 15, 0, 0, 0, 0, time.UTC), To: now, Season: -1}, false},
+		{march, BackfillOptions{From: march, To: time.Date(2023, time.March, 20, 0, 0, 0, 0, time.UTC), Season: -1}, false},
+		{march, BackfillOptions{From: march, To: now, Season: internal.INDOOR}, false},
+		{time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC), BackfillOptions{From: march, To: now, Season: -1}, false},
+	}
+	for i, m := range months {
+		if covered := monthCovered(m.month, m.options, now); covered != m.covered {
+			t.Errorf("Expected month %d to be covered: %t", i, m.covered)
+		}
+	}
+}
@@ -3,8 +3,11 @@ package tfrrs
 import (
 	"bactic/internal"
 	"bactic/internal/database"
+	"bactic/internal/scrapers"
+	"bactic/internal/scrapers/fetch"
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -12,96 +15,86 @@ import (
 	"strings"
 
 	"github.com/PuerkitoBio/goquery"
-	"github.com/antchfx/xmlquery"
 	"github.com/gocolly/colly"
 	"github.com/google/uuid"
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
 )
 
-// Create a new collector that reads
-func NewRSSCollector(db *sql.DB, ctx context.Context) *colly.Collector {
-	rootCollector := colly.NewCollector(colly.AllowURLRevisit())
-	meetCollector := NewMeetCollector(ctx)
-
-	logger := log.New(os.Stdout, "XML RSS", log.LUTC)
-	logger.SetPrefix("XML Root Collector")
-
-	rootCollector.OnRequest(func(r *colly.Request) {
-		logger.Println("Root collector looking at meet RSS", r.URL)
-	})
-	colly.AllowURLRevisit()(rootCollector)
-
-	rootCollector.OnXML("//item", func(x *colly.XMLElement) {
-		select {
-		// ignore all xml meets when cancelled
-		case <-ctx.Done():
-			return
-		default:
-
-			node := x.DOM.(*xmlquery.Node)
-			t := xmlquery.Find(node, "/title")
-			d := xmlquery.Find(node, "/description")
-			l := xmlquery.Find(node, "/link")
-			if len(t) != 1 || len(d) != 1 || len(l) != 1 {
-				logger.Println("Encountered malformed xml item for meet, not scraping")
-				return
-			}
-
-			link := strings.TrimSpace(l[0].InnerText())
-			date, err := parseMeetDate(strings.TrimSpace(d[0].InnerText()))
-			title := strings.TrimSpace(t[0].InnerText())
-			if err != nil {
-				logger.Printf("Unable to parse date string, for meet %s, skipping", title)
-				return
-			}
-			meetID := uuid.New().ID()
-
-			tx, err := db.Begin()
-			if err != nil {
-				panic(err)
-			}
-
-			if err = database.InsertMeet(tx, internal.Meet{
-				ID:   meetID,
-				Name: title,
-				Date: date,
-			}); err != nil {
-				panic(err)
-			}
+// Options of the tfrrs scraper
+type Config struct {
+	// Ingest the full results history listed on the page of each newly found athlete
+	Backfill bool
+	// Scrape meets that were already stored again, updating their details and results in place
+	Refresh bool
+	// Shared client every request is fetched through. One with the default limits is made if nil
+	Transport *fetch.Transport
+	// RSS feed new meets are discovered from, the tfrrs feed of recent results if empty
+	FeedURL string
+}
 
-			meetCtx := colly.NewContext()
-			meetCtx.Put("MeetID", meetID)
-			meetCtx.Put("tx", tx)
-			if err := meetCollector.Request("GET", link, nil, meetCtx, nil); err != nil {
-				panic(err)
-			}
+// The transport of the config, or a new one with the default limits if none was given
+func (c Config) transport() *fetch.Transport {
+	if c.Transport == nil {
+		return fetch.NewTransport(fetch.DefaultConfig)
+	}
+	return c.Transport
+}
 
-			// if we have cancelled, do not insert
-			select {
-			case <-ctx.Done():
-				return
-			default:
-				if err := tx.Commit(); err != nil {
-					panic(err)
-				}
-			}
-		}
-	})
-	return rootCollector
+// Create a collector of meet pages, fetching them and the athlete and school pages they link through the transport
+func NewMeetCollector(ctx context.Context, transport *fetch.Transport) *colly.Collector {
+	if transport == nil {
+		transport = fetch.NewTransport(fetch.DefaultConfig)
+	}
+	return newMeetCollector(ctx, transport, transport.Client())
 }
 
-func NewMeetCollector(ctx context.Context) *colly.Collector {
+// Create a collector of the meet pages served by pages, fetching the athlete and school pages they link with client
+func newMeetCollector(ctx context.Context, pages http.RoundTripper, client *http.Client) *colly.Collector {
 	logger := log.New(os.Stdout, "Meet Collector ", log.Ldate|log.Ltime)
 
-	meetCollector := colly.NewCollector()
+	// meets are revisited when refreshed, and the meet map keeps them from being stored twice
+	meetCollector := colly.NewCollector(colly.AllowURLRevisit())
+	meetCollector.WithTransport(pages)
 
 	meetCollector.OnRequest(func(r *colly.Request) {
 		logger.Println("visiting meet", r.URL)
 	})
 
+	// the meet header lists its dates, venue, host and timing company, and whether an indoor track is banked, flat or oversized
+	meetCollector.OnHTML("div.panel-heading", func(h *colly.HTMLElement) {
+		var items [][]string
+		h.DOM.Find("div.panel-heading-normal-text").Each(func(_ int, s *goquery.Selection) {
+			item := []string{strings.TrimSpace(s.Text())}
+			s.Find("a").Each(func(_ int, a *goquery.Selection) {
+				if href, found := a.Attr("href"); found {
+					item = append(item, href)
+				}
+			})
+			items = append(items, item)
+		})
+		if len(items) == 0 {
+			return
+		}
+
+		tx := h.Request.Ctx.GetAny("tx").(*sql.Tx)
+		meet := h.Request.Ctx.GetAny("Meet").(*internal.Meet)
+		// meets scraped by ID or from the archive are only named by their page
+		if len(meet.Name) == 0 {
+			meet.Name = strings.TrimSpace(h.DOM.Find("h3.panel-title").First().Text())
+		}
+		if hostURL := parseMeetHeader(items, meet); len(hostURL) > 0 {
+			school, _ := checkSchool(tx, client, hostURL, logger)
+			meet.HostSchoolID = school.ID
+		}
+		if err := database.UpdateMeetDetails(tx, *meet); err != nil {
+			panic(err)
+		}
+	})
+
 	meetCollector.OnHTML("div.row", func(h *colly.HTMLElement) {
 		tx := h.Request.Ctx.GetAny("tx").(*sql.Tx)
+		backfill, _ := h.Request.Ctx.GetAny("backfill").(*[]scrapers.MeetRef)
 		resultsRows := h.DOM.Find("tbody>tr")
 		tableLength := resultsRows.Length()
 		if tableLength == 0 {
@@ -110,46 +103,101 @@ func NewMeetCollector(ctx context.Context) *colly.Collector {
 
 		url := h.Request.URL.String()
 		var (
-			eventType internal.EventType
+			heat      internal.Heat
+			heatKey   string
+			teamTable bool
 			err       error
 		)
 
 		if strings.Contains(url, "/xc/") {
-			header := strings.ToLower(strings.Split(h.DOM.Find("div.custom-table-title-xc>h3").Text(), "\n")[0])
-			// TODO: we do not parse team results for now
-			if strings.Contains(header, "team results") {
-				return
-			}
+			title := strings.ToLower(strings.Split(h.DOM.Find("div.custom-table-title-xc>h3").Text(), "\n")[0])
+			teamTable = strings.Contains(title, "team results")
+			// the team and individual tables of a race share its heat
+			heatKey = strings.TrimSpace(xcTableKindRe.ReplaceAllString(title, ""))
 
-			eventType, err = parseXCEventType(header)
+			heat.Type, err = parseXCEventType(title)
 			if err != nil {
 				return
 			}
+			heat.Stage = internal.FINAL
+			heat.Sex = parseSex(title, url)
 		} else { // assume tf otherwise
-			eventType, err = parseEvent(h.DOM.Find("div.custom-table-title>h3").Text())
+			title := h.DOM.Find("div.custom-table-title>h3").Text()
+			heat, err = parseEvent(title)
 			if err != nil {
 				logger.Println("Unable to parse this table type. Assuming a redundant heat table:", err)
 				return
 			}
+			heat.Sex = parseSex(title, url)
+			// the heptathlon contested by men is the indoor combined event
+			if heat.Type == internal.HEPT && heat.Sex == internal.MEN {
+				heat.Type = internal.HEPT_INDOOR
+			}
+			if heat.Type.HasWind() {
+				heat.WindMS = parseHeatWind(h.DOM.Find("div.custom-table-title").Text())
+			}
+		}
+		heat.MeetID = h.Request.Ctx.GetAny("MeetID").(uint32)
+		// championships list the day each event was run alongside its title
+		meet := h.Request.Ctx.GetAny("Meet").(*internal.Meet)
+		if date, found := parseHeatDate(h.DOM.Find("div.custom-table-title, div.custom-table-title-xc").Text(), *meet); found {
+			heat.Date = date
 		}
 
+		// column headers let us find cells whose position varies between tables (attempts, heights)
+		var header []string
+		h.DOM.Find("thead>tr>th").Each(func(i int, s *goquery.Selection) {
+			header = append(header, strings.ToLower(strings.TrimSpace(s.Text())))
+		})
+
 		rowLength := resultsRows.First().Children().Length()
 		table := make([][][]string, tableLength)
 
-		// Collect table into struct indexed by row, column, (text, href)
+		// Collect table into struct indexed by row, column, (text, hrefs...)
 		resultsRows.Each(func(i int, s *goquery.Selection) {
 			table[i] = make([][]string, rowLength)
 			s.Children().Each(func(j int, r *goquery.Selection) {
-				// strip text and link if it exists
+				// strip text and links if they exist. Relay cells link every leg athlete
 				table[i][j] = make([]string, 0, 2)
 				table[i][j] = append(table[i][j], strings.TrimSpace(r.Text()))
-				href, found := r.Children().Attr("href")
-				if found {
-					table[i][j] = append(table[i][j], href)
-				}
+				r.Find("a").Each(func(_ int, a *goquery.Selection) {
+					if href, found := a.Attr("href"); found {
+						table[i][j] = append(table[i][j], href)
+					}
+				})
 			})
 		})
 
+		if teamTable {
+			teamResults, schoolURLs := parseTeamTable(header, table, logger)
+			for i := range teamResults {
+				select {
+				case <-ctx.Done():
+					return
+				default:
+					school, _ := checkSchool(tx, client, schoolURLs[i], logger)
+					teamResults[i].SchoolID = school.ID
+				}
+			}
+			// standings of schools that could not be resolved are dropped
+			scored := teamResults[:0]
+			for _, result := range teamResults {
+				if result.SchoolID != 0 {
+					scored = append(scored, result)
+				}
+			}
+			teamResults = scored
+
+			heatID, err := getHeat(tx, h.Request.Ctx, heatKey, heat)
+			if err != nil {
+				panic(err)
+			}
+			if err := database.InsertTeamResults(tx, heatID, teamResults); err != nil {
+				panic(err)
+			}
+			return
+		}
+
 		/*
 			Athlete ID is a tough case because an athletes name is not a unique identifier.
 			We instead need to use the tfrrs id mapping to verify our own ids. TFRRS
@@ -172,61 +220,208 @@ func NewMeetCollector(ctx context.Context) *colly.Collector {
 			5. if so, then this is not a new athlete. Add the mapped to global relation
 			in the mapping and then follow the global to tfrrs relation
 		*/
-		// parse all information from table
-		resultTable, linkIDs, schoolURLs := parseResultTable(table, logger, eventType)
-		validResults := make([]internal.Result, 0)
-
-		for i, link := range linkIDs {
-			select {
-			// this is the expensive step of the page scrape, meaning we cancel here when context.Done channel is closed
-			case <-ctx.Done():
-				return
-			default:
-				id, err, httpError := checkAthlete(tx, link, logger)
-				if err != nil {
-					panic(err)
-				} else if httpError {
-					continue
+		insertResults := func() {
+			// parse all information from table
+			resultTable, linkIDs, schoolURLs := parseResultTable(header, table, logger, heat.Type)
+			validResults := make([]internal.Result, 0)
+
+			for i, link := range linkIDs {
+				select {
+				// this is the expensive step of the page scrape, meaning we cancel here when context.Done channel is closed
+				case <-ctx.Done():
+					return
+				default:
+					result := resultTable[i]
+					athletes := []uint32{}
+					if len(result.Members) > 0 {
+						// relay results have no single athlete, so we resolve each leg through the same mapping
+						for leg, member := range result.Members {
+							id, err, fetchErr := checkAthlete(tx, client, member, backfill, logger)
+							if err != nil {
+								panic(err)
+							} else if fetchErr != nil {
+								logger.Printf("Could not resolve leg %d of relay, leaving it unlinked", leg+1)
+								markUnresolved(h.Request.Ctx, fetchErr)
+								result.Members[leg] = 0
+								continue
+							}
+							result.Members[leg] = id
+							athletes = append(athletes, id)
+						}
+					} else {
+						id, err, fetchErr := checkAthlete(tx, client, link, backfill, logger)
+						if err != nil {
+							panic(err)
+						} else if fetchErr != nil {
+							markUnresolved(h.Request.Ctx, fetchErr)
+							continue
+						}
+						result.AthleteID = id
+						athletes = append(athletes, id)
+					}
+
+					school, fetchErr := checkSchool(tx, client, schoolURLs[i], logger)
+					if fetchErr != nil && len(schoolURLs[i]) > 0 {
+						markUnresolved(h.Request.Ctx, fetchErr)
+					}
+					result.SchoolID = school.ID
+					result.Stage = heat.Stage
+					// athletes without their own reading ran with the heat's wind
+					if result.WindMS == nil {
+						result.WindMS = heat.WindMS
+					}
+					validResults = append(validResults, result)
+					for _, athleteID := range athletes {
+						if school.ID == 0 {
+							break
+						}
+						if err := database.AddAthleteToSchool(tx, athleteID, school.ID); err != nil {
+							logger.Panic(err, school.ID)
+						}
+					}
 				}
-				resultTable[i].AthleteID = id
-				validResults = append(validResults, resultTable[i])
+			}
 
-				school := checkSchool(tx, schoolURLs[i], logger)
-				if err := database.AddAthleteToSchool(tx, resultTable[i].AthleteID, school.ID); err != nil {
-					logger.Panic(err, school.ID)
-				}
+			// finally, insert the heat, unless every result in it was already listed by another table of the round
+			listed := len(validResults)
+			validResults = dedupeResults(h.Request.Ctx, heat, validResults)
+			if listed > 0 && len(validResults) == 0 {
+				return
+			}
+			heatID, err := getHeat(tx, h.Request.Ctx, heatKey, heat)
+			if err != nil {
+				panic(err)
+			}
+			if err := database.InsertResults(tx, heatID, validResults); err != nil {
+				panic(err)
 			}
 		}
 
-		// finally, insert the heat
-		meetID := h.Request.Ctx.GetAny("MeetID").(uint32)
-		_, err = database.InsertHeat(tx, eventType, meetID, validResults)
-		if err != nil {
-			panic(err)
+		// a round listed both as one compiled table and as a table per heat or section is read from its heats first, so
+		// that the compiled listing only keeps the results no heat lists
+		if !strings.Contains(url, "/xc/") && heat.Number == 0 && heat.Section == 0 {
+			compiled, _ := h.Request.Ctx.GetAny("compiled").([]func())
+			h.Request.Ctx.Put("compiled", append(compiled, insertResults))
+			return
+		}
+		insertResults()
+	})
+
+	meetCollector.OnScraped(func(r *colly.Response) {
+		compiled, _ := r.Ctx.GetAny("compiled").([]func())
+		r.Ctx.Put("compiled", []func(){})
+		for _, insertResults := range compiled {
+			insertResults()
 		}
 	})
 	return meetCollector
 }
 
-// How we scrape athletes since there are some scraping dependencies that are challenging to handle through collys functional scraping mechanisms
-func checkAthlete(tx *sql.Tx, linkID uint32, logger *log.Logger) (athleteID uint32, err error, httpError bool) {
+// Count a row of the page whose athlete or school could not be resolved. Its stored result would be taken for one the
+// page no longer lists, so a refresh of the meet is rolled back instead. Rows that failed for a reason that may pass,
+// such as a 503 that outlasted its retries, fail the scrape of a new meet too, so that it is scraped on a later pass
+func markUnresolved(ctx *colly.Context, err error) {
+	unresolved, _ := ctx.GetAny("unresolved").(int)
+	ctx.Put("unresolved", unresolved+1)
+	if transient(err) {
+		failed, _ := ctx.GetAny("transient").(int)
+		ctx.Put("transient", failed+1)
+	}
+}
+
+// A page answered with an error status
+type statusError struct {
+	url    string
+	status int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("fetching %s: %d %s", e.url, e.status, http.StatusText(e.status))
+}
+
+// Whether a fetch failed for a reason that may pass, unlike a page that is missing, disallowed or not archived
+func transient(err error) bool {
+	var statusErr *statusError
+	if errors.As(err, &statusErr) {
+		return statusErr.status == http.StatusTooManyRequests || statusErr.status >= 500
+	}
+	return !errors.Is(err, fetch.ErrNotArchived) && !errors.Is(err, fetch.ErrDisallowed)
+}
+
+// Some meets list a round both as one table and as a table per heat or section. Keep only the first
+// listing of each athlete's (or relay team's) result in a round, so that it is not counted twice. Compiled
+// tables are read last, so the first listing is the one in the athlete's heat or section
+func dedupeResults(ctx *colly.Context, heat internal.Heat, results []internal.Result) []internal.Result {
+	deduped := make([]internal.Result, 0, len(results))
+	for _, result := range results {
+		key := fmt.Sprintf("result:%d:%d:%d:%d:%d:%s", heat.Type, heat.Sex, heat.Stage, result.AthleteID, result.SchoolID, result.Team)
+		if ctx.GetAny(key) != nil {
+			continue
+		}
+		ctx.Put(key, true)
+		deduped = append(deduped, result)
+	}
+	return deduped
+}
+
+// Get the heat that a results table belongs to. Tables with the same non-empty key on a meet page
+// are of the same race and share a heat, otherwise each table is its own heat
+func getHeat(tx *sql.Tx, ctx *colly.Context, key string, heat internal.Heat) (uint32, error) {
+	if len(key) > 0 {
+		if heatID, found := ctx.GetAny("heat:" + key).(uint32); found {
+			return heatID, nil
+		}
+	}
+
+	// meets can hold several races of an event, such as varsity and open races or open and invitational sections,
+	// that are listed without a heat or section telling them apart. They are numbered in page order past the races
+	// already read, so that they are not merged into one heat
+	for {
+		raceKey := fmt.Sprintf("race:%d:%d:%d:%d:%d", heat.Type, heat.Sex, heat.Stage, heat.Number, heat.Section)
+		if ctx.GetAny(raceKey) == nil {
+			ctx.Put(raceKey, true)
+			break
+		}
+		heat.Number++
+	}
+
+	heatID, err := database.InsertHeat(tx, heat, nil)
+	if err != nil {
+		return 0, err
+	}
+	if len(key) > 0 {
+		ctx.Put("heat:"+key, heatID)
+	}
+	return heatID, nil
+}
+
+// Resolve an athlete link to the athlete's bactic ID, creating the athlete from their page if they are new. When
+// backfill is set, the meets listed on a new athlete's page are queued onto it. An athlete whose page could not be
+// fetched is left unresolved, with the reason returned as fetchErr
+func checkAthlete(tx *sql.Tx, client *http.Client, linkID uint32, backfill *[]scrapers.MeetRef, logger *log.Logger) (athleteID uint32, err error, fetchErr error) {
 	tfrrsID, found := database.GetAthleteRelation(tx, linkID)
 	// if the link ID is in the table, we return what we find directly
 	if found {
 		bacticID, found := database.GetAthleteRelation(tx, tfrrsID)
 		if !found {
-			return tfrrsID, nil, false
+			return tfrrsID, nil, nil
 		} else {
-			return bacticID, nil, false
+			return bacticID, nil, nil
 		}
 	}
 
 	// otherwise, we follow the link to validate the tfrrs id
-	resp, err := http.Get(fmt.Sprintf("https://www.tfrrs.org/athletes/%v", linkID))
+	athleteURL := fmt.Sprintf("https://www.tfrrs.org/athletes/%v", linkID)
+	resp, err := client.Get(athleteURL)
 	if err != nil {
-		panic(err)
-	} else if resp.StatusCode > 400 {
-		return 0, nil, true
+		// athletes whose page robots.txt disallows, that failed every retry, or that are missing from a replayed archive
+		// are left unresolved
+		logger.Println(err)
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return 0, nil, &statusError{url: athleteURL, status: resp.StatusCode}
 	}
 	doc, err := goquery.NewDocumentFromReader(resp.Body)
 	if err != nil {
@@ -244,7 +439,7 @@ func checkAthlete(tx *sql.Tx, linkID uint32, logger *log.Logger) (athleteID uint
 		if err = database.AddAthleteRelation(tx, linkID, tfrrsID); err != nil {
 			panic(err)
 		}
-		return bacticID, nil, false
+		return bacticID, nil, nil
 	}
 
 	// we have to create a new athlete
@@ -258,30 +453,73 @@ func checkAthlete(tx *sql.Tx, linkID uint32, logger *log.Logger) (athleteID uint
 		}
 	}
 
-	c := cases.Title(language.AmericanEnglish)
+	// the title lists the athlete's name followed by their class year, and the header their hometown
+	athlete := internal.Athlete{ID: bacticID}
 	h := doc.Selection.Find("h3.panel-title.large-title")
-	athName := c.String(strings.Split(strings.TrimSpace(h.Text()), "\n")[0])
-	logger.Printf("Found new athlete %s, scraping", athName)
+	lines := strings.Split(strings.TrimSpace(h.Text()), "\n")
+	lines = append(lines, strings.Split(h.Closest("div.panel-heading").Text(), "\n")...)
+	parseAthleteTitle(lines, &athlete)
+	logger.Printf("Found new athlete %s, scraping", athlete.Name)
+
+	// the athlete's results are listed under a heading for each season they were on the roster
+	var school internal.School
+	if schoolURL, found := doc.Selection.Find("div.panel-heading a[href*='/teams/']").First().Attr("href"); found {
+		school, _ = checkSchool(tx, client, schoolURL, logger)
+	}
+	if school.ID != 0 {
+		var headings []string
+		doc.Selection.Find("h3, h4, th, div.panel-heading, option").Each(func(_ int, s *goquery.Selection) {
+			headings = append(headings, s.Text())
+		})
+		athlete.Schools = []uint32{school.ID}
+		athlete.Rosters = parseRosters(headings, athlete, school.ID)
+	}
 
-	if err := database.InsertAthlete(tx, internal.Athlete{
-		ID:   bacticID,
-		Name: athName,
-	}); err != nil {
+	if err := database.InsertAthlete(tx, athlete); err != nil {
 		panic(err)
 	}
-	return bacticID, nil, false
+
+	// the athlete's page links every meet they competed at, headed by its name and date
+	if backfill != nil {
+		queued := make(map[string]bool)
+		doc.Selection.Find("a[href*='/results/']").Each(func(_ int, a *goquery.Selection) {
+			href, _ := a.Attr("href")
+			// results rows link the meet's event pages too, which share its key
+			key, err := parseMeetKey(href)
+			if err != nil || queued[key] {
+				return
+			}
+			meet, err := parseAthleteMeet(a.Parent().Text(), a.Text(), href)
+			if err != nil {
+				logger.Printf("Unable to queue meet %s of athlete %s for backfill: %v", href, athlete.Name, err)
+				return
+			}
+			queued[key] = true
+			*backfill = append(*backfill, meet)
+		})
+	}
+	return bacticID, nil, nil
 }
 
-// checks the url string for existence. If not, scrape the school and then insert. Otherwise, insert the school
-func checkSchool(tx *sql.Tx, url string, logger *log.Logger) internal.School {
+// checks the url string for existence. If not, scrape the school and then insert. Otherwise, insert the school. A
+// school whose page could not be fetched is returned empty, along with the reason
+func checkSchool(tx *sql.Tx, client *http.Client, url string, logger *log.Logger) (internal.School, error) {
 	school, found := database.GetSchoolURL(tx, url)
 	if found {
-		return school
+		return school, nil
 	}
 
-	resp, err := http.Get(url)
+	resp, err := client.Get(url)
 	if err != nil {
-		panic(err)
+		// a school whose page robots.txt disallows, that failed every retry, or that is missing from a replayed archive is
+		// left unlinked
+		logger.Println(err)
+		return internal.School{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		logger.Printf("Could not fetch school %s: %s", url, resp.Status)
+		return internal.School{}, &statusError{url: url, status: resp.StatusCode}
 	}
 	doc, err := goquery.NewDocumentFromReader(resp.Body)
 	if err != nil {
@@ -322,5 +560,5 @@ func checkSchool(tx *sql.Tx, url string, logger *log.Logger) internal.School {
 	if err != nil {
 		panic(err)
 	}
-	return school
+	return school, nil
 }
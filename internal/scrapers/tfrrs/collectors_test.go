@@ -4,9 +4,11 @@ package tfrrs_test
 import (
 	"bactic/internal"
 	"bactic/internal/database"
+	"bactic/internal/scrapers"
 	"bactic/internal/scrapers/tfrrs"
 	"context"
 	"database/sql"
+	"log"
 	"net/http"
 	"testing"
 	"time"
@@ -39,15 +41,17 @@ func TestScraperTFMeet(t *testing.T) {
 	tx := newTx()
 
 	meetID := uint32(79700)
-	collector := tfrrs.NewMeetCollector(context.Background())
-	database.InsertMeet(tx, internal.Meet{
+	collector := tfrrs.NewMeetCollector(context.Background(), nil)
+	meet := internal.Meet{
 		ID:     meetID,
 		Name:   "2023 SCIAC TF Championships",
 		Season: internal.OUTDOOR,
 		Date:   time.Date(2023, time.April, 29, 0, 0, 0, 0, time.UTC),
-	})
+	}
+	database.InsertMeet(tx, meet)
 	ctx := colly.NewContext()
 	ctx.Put("MeetID", meetID)
+	ctx.Put("Meet", &meet)
 	ctx.Put("tx", tx)
 	if err := collector.Request("GET", "https://tfrrs.org/results/79700/m/2023_SCIAC_TF_Championships", nil, ctx, nil); err != nil {
 		t.Fatal(err)
@@ -63,15 +67,17 @@ func TestScraperXCMeet(t *testing.T) {
 	tx := newTx()
 
 	meetID := uint32(23293)
-	collector := tfrrs.NewMeetCollector(context.Background())
-	database.InsertMeet(tx, internal.Meet{
+	collector := tfrrs.NewMeetCollector(context.Background(), nil)
+	meet := internal.Meet{
 		ID:     meetID,
 		Name:   "2023 SCIAC Cross Country Championships",
 		Season: internal.XC,
 		Date:   time.Date(2023, time.October, 28, 0, 0, 0, 0, time.UTC),
-	})
+	}
+	database.InsertMeet(tx, meet)
 	ctx := colly.NewContext()
 	ctx.Put("MeetID", meetID)
+	ctx.Put("Meet", &meet)
 	ctx.Put("tx", tx)
 	if err := collector.Request("GET", "https://tfrrs.org/results/xc/23218/2023_SCIAC_Cross_Country_Championships", nil, ctx, nil); err != nil {
 		t.Fatal(err)
@@ -85,13 +91,15 @@ func TestScraperXCMeet(t *testing.T) {
 
 func TestScraperRoot(t *testing.T) {
 	db := newDB()
-	rss := tfrrs.NewRSSCollector(db, context.Background())
+	source := tfrrs.NewSource(tfrrs.Config{FeedURL: "http://127.0.0.1:8080"})
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		http.ServeFile(w, r, "../../../test/tfrrs_test.rss")
 	})
 	go http.ListenAndServe(":8080", nil)
 
-	if err := rss.Visit("http://127.0.0.1:8080"); err != nil {
+	meets, err := source.Discover(context.Background())
+	if err != nil {
 		panic(err)
 	}
+	scrapers.ScrapeAll(db, context.Background(), source, meets, false, log.Default())
 }
@@ -2,6 +2,8 @@ package tfrrs
 
 import (
 	"bactic/internal"
+	"bactic/internal/scrapers"
+	"bactic/internal/stats"
 	"errors"
 	"fmt"
 	"log"
@@ -10,13 +12,143 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
 )
 
-// Parse a date string in the tfrrs website format
-func parseMeetDate(date string) (time.Time, error) {
-	replace_daterange := regexp.MustCompile(`-\d{1,2}`)
-	date = string(replace_daterange.ReplaceAll([]byte(date), []byte("")))
-	return time.Parse("January 2, 2006", date)
+var meetDatesRe = regexp.MustCompile(`^([A-Za-z]+)\.?\s+(\d{1,2})(?:,\s*(\d{4}))?(?:\s*(?:-|–|to)\s*(?:([A-Za-z]+)\.?\s+)?(\d{1,2}))?,\s*(\d{4})$`)
+
+// Parse a date string in the tfrrs website format into the first and last day of the meet. Accepts single days
+// (May 6, 2023) and ranges within a month (May 5-6, 2023), across months (Feb 28-Mar 1, 2024) or across years
+func parseMeetDates(date string) (start time.Time, end time.Time, err error) {
+	matches := meetDatesRe.FindStringSubmatch(strings.Join(strings.Fields(date), " "))
+	if matches == nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("meet date could not be parsed: %s", date)
+	}
+	startYear, endMonth, endDay := matches[3], matches[4], matches[5]
+	if len(startYear) == 0 {
+		startYear = matches[6]
+	}
+	if len(endMonth) == 0 {
+		endMonth = matches[1]
+	}
+	if len(endDay) == 0 {
+		endDay = matches[2]
+	}
+
+	if start, err = parseMonthDay(matches[1], matches[2], startYear); err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if end, err = parseMonthDay(endMonth, endDay, matches[6]); err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return start, end, nil
+}
+
+// Parse a date with a full or abbreviated month name
+func parseMonthDay(month string, day string, year string) (time.Time, error) {
+	date := fmt.Sprintf("%s %s %s", month, day, year)
+	if t, err := time.Parse("January 2 2006", date); err == nil {
+		return t, nil
+	}
+	return time.Parse("Jan 2 2006", date)
+}
+
+var heatDateRe = regexp.MustCompile(`(?i)\b(jan(?:uary)?|feb(?:ruary)?|mar(?:ch)?|apr(?:il)?|may|june?|july?|aug(?:ust)?|sep(?:t(?:ember)?)?|oct(?:ober)?|nov(?:ember)?|dec(?:ember)?)\.?\s+(\d{1,2})\b(?:,\s*(\d{4}))?`)
+
+// Given the title of an event table, return the day it was run. Titles often leave out the year, which is taken from
+// the days of the meet
+func parseHeatDate(title string, meet internal.Meet) (time.Time, bool) {
+	matches := heatDateRe.FindStringSubmatch(title)
+	if matches == nil {
+		return time.Time{}, false
+	}
+	month := strings.ToUpper(matches[1][:1]) + strings.ToLower(matches[1][1:3])
+	year := matches[3]
+	if len(year) == 0 {
+		year = strconv.Itoa(meet.Date.Year())
+	}
+	date, err := time.Parse("Jan 2 2006", fmt.Sprintf("%s %s %s", month, matches[2], year))
+	if err != nil {
+		return time.Time{}, false
+	}
+	// meets that run over the new year end in the following year
+	if len(matches[3]) == 0 && date.Before(meet.Date) && meet.EndDate.Year() > meet.Date.Year() {
+		date = date.AddDate(1, 0, 0)
+	}
+	return date, true
+}
+
+var (
+	headerLabelRe     = regexp.MustCompile(`(?i)^(hosted by|host|venue|facility|track surface|surface|track size|timing company|timing by|timed by|timing|results by)\s*:?\s*(.+)$`)
+	headerLocationRe  = regexp.MustCompile(`^(?:(.+?),\s*)?([A-Za-z .'’-]+),\s*([A-Z]{2})$`)
+	headerTrackNoteRe = regexp.MustCompile(`\s*\([^)]*\)\s*$`)
+	trackSizeRe       = regexp.MustCompile(`(?i)\b(\d{3})\s*m(?:eters?)?\b`)
+)
+
+// Given the items of a meet page header as text and links, fill in the meet's dates, venue, location, host, track and
+// timing company. Items may hold several details separated by a pipe. Returns the link to the host school, if any
+func parseMeetHeader(items [][]string, meet *internal.Meet) (hostURL string) {
+	for _, item := range items {
+		if len(item) == 0 {
+			continue
+		}
+		if trackType := parseTrackType(item[0]); trackType != internal.UNKNOWN_TRACK {
+			meet.TrackType = trackType
+		}
+
+		var unlabeled []string
+		located := false
+		for _, part := range strings.Split(item[0], "|") {
+			part = strings.Join(strings.Fields(part), " ")
+			if len(part) == 0 {
+				continue
+			}
+
+			if start, end, err := parseMeetDates(part); err == nil {
+				meet.Date, meet.EndDate = start, end
+			} else if matches := headerLabelRe.FindStringSubmatch(part); matches != nil {
+				value := strings.TrimSpace(matches[2])
+				switch label := strings.ToLower(matches[1]); label {
+				case "hosted by", "host":
+					meet.Host = value
+					// only team pages identify the host school
+					if len(item) > 1 && strings.Contains(item[1], "/teams/") {
+						hostURL = item[1]
+					}
+				case "venue", "facility":
+					meet.Venue = headerTrackNoteRe.ReplaceAllString(value, "")
+				case "track surface", "surface":
+					meet.Surface = value
+				case "track size":
+					if size := trackSizeRe.FindStringSubmatch(value); size != nil {
+						meet.TrackSize = int(parseInt64(size[1]))
+					}
+				default:
+					meet.TimingCompany = value
+				}
+			} else if matches := headerLocationRe.FindStringSubmatch(headerTrackNoteRe.ReplaceAllString(part, "")); matches != nil {
+				located = true
+				if len(matches[1]) > 0 {
+					meet.Venue = matches[1]
+				}
+				meet.City, meet.State = strings.TrimSpace(matches[2]), matches[3]
+			} else {
+				unlabeled = append(unlabeled, part)
+			}
+
+			if size := trackSizeRe.FindStringSubmatch(part); size != nil && strings.Contains(strings.ToLower(part), "track") {
+				meet.TrackSize = int(parseInt64(size[1]))
+			}
+		}
+
+		// a facility listed alongside the city is the venue
+		if located && len(meet.Venue) == 0 && len(unlabeled) == 1 {
+			meet.Venue = headerTrackNoteRe.ReplaceAllString(unlabeled[0], "")
+		}
+	}
+	return hostURL
 }
 
 func parseDivision(region string) int {
@@ -70,50 +202,355 @@ func parseInt64(value string) int64 {
 	}
 }
 
+var timeRe = regexp.MustCompile(`^(?:(\d+):)?(?:(\d+):)?(\d+)(?:\.(\d+))?(?:\s*([hH])\b)?`)
+
+// Parse a time such as 10.85, 4:05.12 or 1:02:03 into seconds
 func parseTime(t string) (float32, error) {
-	if slices.Contains([]string{"DNF", "DQ", "FS", "DNS", "NT"}, t) {
-		return 0.0, &internal.TimingError{Name: t}
-	} else {
-		time_regexp := regexp.MustCompile(`(\d+:)?(\d+).(\d+)`)
-		matches := time_regexp.FindStringSubmatch(t)
-		if len(matches) != 4 {
-			return 0.0, errors.New("time could not be parsed into expected format string: t")
+	time, _, err := parseTimeMethod(t, false)
+	return time, err
+}
+
+// Parse a time into seconds along with its timing method. Times marked with a trailing h are hand timed. Since sprint
+// and hurdle FAT times are read to the hundredth, times read to the tenth or whole second are taken as hand timed when
+// inferHand is set
+func parseTimeMethod(t string, inferHand bool) (float32, int, error) {
+	t = strings.TrimSpace(t)
+	if fields := strings.Fields(t); len(fields) > 0 && slices.Contains([]string{"DNF", "DQ", "FS", "DNS", "NT"}, strings.ToUpper(fields[0])) {
+		return 0.0, internal.FAT, &internal.TimingError{Name: t}
+	}
+
+	matches := timeRe.FindStringSubmatch(t)
+	if matches == nil {
+		return 0.0, internal.FAT, fmt.Errorf("time could not be parsed into expected format: %s", t)
+	}
+	hours, minutes := int64(0), parseInt64(matches[1])
+	if len(matches[2]) > 0 {
+		hours, minutes = minutes, parseInt64(matches[2])
+	}
+	seconds, err := strconv.ParseFloat(matches[3]+"."+matches[4], 64)
+	if err != nil {
+		return 0.0, internal.FAT, err
+	}
+
+	timing := internal.FAT
+	if len(matches[5]) > 0 || (inferHand && len(matches[4]) < 2) {
+		timing = internal.HAND
+	}
+	return float32(float64(hours*3600+minutes*60) + seconds), timing, nil
+}
+
+var (
+	metricMarkRe   = regexp.MustCompile(`^(\d+\.\d+)m?\b`)
+	feetInchMarkRe = regexp.MustCompile(`^(\d+)(?:-|'\s*)(\d+(?:\.\d+)?)"?`)
+)
+
+// Timing conditions recorded in place of a time
+var timingStatuses = map[string]int{
+	"DNF": internal.DNF,
+	"DNS": internal.DNS,
+	"DQ":  internal.DQ,
+	"FS":  internal.FS,
+	"NT":  internal.NT,
+}
+
+// Parse a time into the result along with its timing method. Timing conditions such as DNF are kept as the result
+// status, along with the rule code of a disqualification such as DQ (163-3a)
+func parseTimedResult(t string, result *internal.Result, inferHand bool) error {
+	time, timing, err := parseTimeMethod(t, inferHand)
+	var timingErr *internal.TimingError
+	if errors.As(err, &timingErr) {
+		fields := strings.Fields(timingErr.Name)
+		result.Status = timingStatuses[strings.ToUpper(fields[0])]
+		if result.Status == internal.DQ && len(fields) > 1 {
+			result.DQCode = strings.Trim(strings.Join(fields[1:], " "), "()[]")
 		}
-		minutes := time.Duration(parseInt64(matches[1]))
-		seconds := time.Duration(parseInt64(matches[2]))
-		frac := time.Duration(parseInt64(matches[3])) * 10
+		return nil
+	} else if err != nil {
+		return err
+	}
+	result.Quantity = time
+	result.Timing = timing
+	return nil
+}
 
-		return float32((minutes*time.Minute + seconds*time.Second + frac*time.Millisecond).Seconds()), nil
+// Look up the status of a mark that is recorded without a quantity, such as FOUL or DNS
+func lookupStatus(mark string) (int, bool) {
+	if status, found := fieldStatuses[strings.ToUpper(mark)]; found {
+		return status, true
 	}
+	status, found := timingStatuses[strings.ToUpper(mark)]
+	return status, found
+}
+
+// Field event marks that are recorded without a distance or height
+var fieldStatuses = map[string]int{
+	"FOUL": internal.FOUL,
+	"PASS": internal.PASS,
+	"NH":   internal.NO_HEIGHT,
+	"ND":   internal.NO_DISTANCE,
+	"NM":   internal.NO_DISTANCE,
+}
+
+// Parse a field event mark into meters. Accepts metric marks (7.45m) and feet-inch marks (6-10.75 or 6' 10.75")
+func parseMark(m string) (float32, error) {
+	m = strings.TrimSpace(m)
+	if matches := metricMarkRe.FindStringSubmatch(m); len(matches) == 2 {
+		meters, err := strconv.ParseFloat(matches[1], 32)
+		if err != nil {
+			return 0.0, err
+		}
+		return float32(meters), nil
+	}
+
+	if matches := feetInchMarkRe.FindStringSubmatch(m); len(matches) == 3 {
+		feet, err := strconv.ParseFloat(matches[1], 64)
+		if err != nil {
+			return 0.0, err
+		}
+		inches, err := strconv.ParseFloat(matches[2], 64)
+		if err != nil {
+			return 0.0, err
+		}
+		if inches >= 12 {
+			return 0.0, fmt.Errorf("mark %s has more than 12 inches", m)
+		}
+		return float32((feet*12 + inches) * 0.0254), nil
+	}
+
+	return 0.0, fmt.Errorf("mark could not be parsed into meters or feet-inches: %s", m)
 }
 
 var titleToEventEnum = map[string]internal.EventType{
-	"5000 meters":       internal.T5000M,
-	"5,000 meters":      internal.T5000M,
-	"100 meters":        internal.T100M,
-	"200 meters":        internal.T200M,
-	"400 meters":        internal.T400M,
-	"800 meters":        internal.T800M,
-	"1500 meters":       internal.T1500M,
-	"10,000 meters":     internal.T10000M,
-	"110 hurdles":       internal.T110H,
-	"400 hurdles":       internal.T400H,
-	"3000 steeplechase": internal.T3000S,
-	"3000 meters":       internal.T3000M,
-	"4 x 100m relay":    internal.T4X100,
-	"4 x 100 relay":     internal.T4X100,
-	"4 x 400 relay":     internal.T4X400,
-	"high jump":         internal.HIGH_JUMP,
-	"pole vault":        internal.VAULT,
-	"long jump":         internal.LONG_JUMP,
-	"triple jump":       internal.TRIPLE_JUMP,
-	"shot put":          internal.SHOT,
-	"discus":            internal.DISCUS,
-	"hammer":            internal.HAMMER,
-	"javelin":           internal.JAV,
-	"decathlon":         internal.DEC,
-	"heptathlon":        internal.HEPT,
-	"100 hurdles":       internal.T100H,
+	"5000 meters":           internal.T5000M,
+	"5,000 meters":          internal.T5000M,
+	"100 meters":            internal.T100M,
+	"200 meters":            internal.T200M,
+	"400 meters":            internal.T400M,
+	"800 meters":            internal.T800M,
+	"1500 meters":           internal.T1500M,
+	"10,000 meters":         internal.T10000M,
+	"110 hurdles":           internal.T110H,
+	"400 hurdles":           internal.T400H,
+	"3000 steeplechase":     internal.T3000S,
+	"3000 meters":           internal.T3000M,
+	"4 x 100m relay":        internal.T4X100,
+	"4 x 100 relay":         internal.T4X100,
+	"4 x 400 relay":         internal.T4X400,
+	"high jump":             internal.HIGH_JUMP,
+	"pole vault":            internal.VAULT,
+	"long jump":             internal.LONG_JUMP,
+	"triple jump":           internal.TRIPLE_JUMP,
+	"shot put":              internal.SHOT,
+	"discus":                internal.DISCUS,
+	"hammer":                internal.HAMMER,
+	"javelin":               internal.JAV,
+	"decathlon":             internal.DEC,
+	"heptathlon":            internal.HEPT,
+	"100 hurdles":           internal.T100H,
+	"60 meters":             internal.T60M,
+	"60 hurdles":            internal.T60H,
+	"300 meters":            internal.T300M,
+	"500 meters":            internal.T500M,
+	"600 meters":            internal.T600M,
+	"1000 meters":           internal.T1000M,
+	"mile":                  internal.MILE,
+	"1 mile":                internal.MILE,
+	"distance medley relay": internal.DMR,
+	"dmr":                   internal.DMR,
+	"4 x 400m relay":        internal.T4X400,
+	"weight throw":          internal.WEIGHT,
+	"pentathlon":            internal.PENT,
+}
+
+var (
+	oversizedTrackRe = regexp.MustCompile(`(?i)\boversized?\b`)
+	bankedTrackRe    = regexp.MustCompile(`(?i)\bbanked\b`)
+	flatTrackRe      = regexp.MustCompile(`(?i)\bflat\b`)
+)
+
+// Given a meet's result link, date and the events read from it, return its season. Cross country results are listed
+// under /results/xc/, while track meets are indoor or outdoor by their events and date
+func parseSeason(link string, date time.Time, events []internal.EventType) int {
+	if strings.Contains(link, "/results/xc/") {
+		return internal.XC
+	}
+	if season := internal.InferSeason(events, date); season != internal.XC {
+		return season
+	}
+	return internal.INDOOR
+}
+
+// Given the meet page's header, return the indoor track type it notes, if any
+func parseTrackType(header string) int {
+	switch {
+	case oversizedTrackRe.MatchString(header):
+		return internal.OVERSIZED_TRACK
+	case bankedTrackRe.MatchString(header):
+		return internal.BANKED_TRACK
+	case flatTrackRe.MatchString(header):
+		return internal.FLAT_TRACK
+	}
+	return internal.UNKNOWN_TRACK
+}
+
+var (
+	classYearRe  = regexp.MustCompile(`(?i)^(?:(rs|r)[- ]?)?(fr|so|jr|sr|5th|gr)(?:-(\d))?(?:\s*\((rs|r)\))?$`)
+	hometownRe   = regexp.MustCompile(`(?i)hometown\s*:?\s*([^\n|]+)`)
+	rosterSeason = regexp.MustCompile(`(?i)^(\d{4})\s+(indoor|outdoor|cross country|xc)\b`)
+)
+
+var classYears = map[string]int{
+	"fr":  internal.FRESHMAN,
+	"so":  internal.SOPHOMORE,
+	"jr":  internal.JUNIOR,
+	"sr":  internal.SENIOR,
+	"5th": internal.FIFTH_YEAR,
+	"gr":  internal.GRADUATE,
+}
+
+// Parse a class year as listed on tfrrs, such as SO-2, RS-JR or FR (RS), into the athlete's class, year of eligibility
+// and redshirt status. Returns false if the text is not a class year
+func parseClassYear(class string, athlete *internal.Athlete) bool {
+	matches := classYearRe.FindStringSubmatch(strings.TrimSpace(class))
+	if matches == nil {
+		return false
+	}
+	athlete.Year = classYears[strings.ToLower(matches[2])]
+	athlete.Eligibility = int(parseInt64(matches[3]))
+	athlete.Redshirt = len(matches[1]) > 0 || len(matches[4]) > 0
+	return true
+}
+
+// Given the lines of an athlete page's title, fill in the athlete's name, class year and hometown
+func parseAthleteTitle(lines []string, athlete *internal.Athlete) {
+	titleCaser := cases.Title(language.AmericanEnglish)
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if i == 0 {
+			athlete.Name = titleCaser.String(line)
+			continue
+		}
+		if matches := hometownRe.FindStringSubmatch(line); matches != nil {
+			athlete.Hometown = strings.TrimSpace(matches[1])
+			continue
+		}
+		// the class year may share a line with other details, such as the athlete's event group
+		for _, field := range strings.FieldsFunc(line, func(r rune) bool { return r == '|' || r == ',' }) {
+			if parseClassYear(field, athlete) {
+				break
+			}
+		}
+	}
+}
+
+// Given the season headings of an athlete page, such as 2023 Outdoor, return the athlete's roster on the school for each
+// season. Class years are counted back from the athlete's current class year in their latest season
+func parseRosters(headings []string, athlete internal.Athlete, schoolID uint32) []internal.Roster {
+	var rosters []internal.Roster
+	seen := make(map[[2]int]bool)
+	latest := 0
+	for _, heading := range headings {
+		matches := rosterSeason.FindStringSubmatch(strings.TrimSpace(heading))
+		if matches == nil {
+			continue
+		}
+		season := internal.OUTDOOR
+		switch strings.ToLower(matches[2]) {
+		case "indoor":
+			season = internal.INDOOR
+		case "cross country", "xc":
+			season = internal.XC
+		}
+		year := int(parseInt64(matches[1]))
+		if seen[[2]int{year, season}] {
+			continue
+		}
+		seen[[2]int{year, season}] = true
+		rosters = append(rosters, internal.Roster{AthleteID: athlete.ID, SchoolID: schoolID, Year: year, Season: season})
+		latest = max(latest, internal.AcademicYear(year, season))
+	}
+
+	for i := range rosters {
+		if athlete.Year == internal.UNKNOWN_CLASS || athlete.Year == internal.GRADUATE {
+			continue
+		}
+		class := athlete.Year - (latest - internal.AcademicYear(rosters[i].Year, rosters[i].Season))
+		if class >= internal.FRESHMAN {
+			rosters[i].ClassYear = class
+		}
+	}
+	return rosters
+}
+
+var (
+	meetKeyRe       = regexp.MustCompile(`/results/(xc/)?(\d+)`)
+	athleteMeetDate = regexp.MustCompile(`[A-Za-z]+\.?\s+\d{1,2}(?:,\s*\d{4})?(?:\s*(?:-|–|to)\s*(?:[A-Za-z]+\.?\s+)?\d{1,2})?,\s*\d{4}`)
+)
+
+// Given a meet's result link, return the key identifying it on tfrrs. Cross country and track meets are numbered
+// separately, so the key notes which the meet is
+func parseMeetKey(url string) (string, error) {
+	matches := meetKeyRe.FindStringSubmatch(url)
+	if matches == nil {
+		return "", fmt.Errorf("no meet id found in url %s", url)
+	}
+	if len(matches[1]) > 0 {
+		return "tfrrs/xc/" + matches[2], nil
+	}
+	return "tfrrs/tf/" + matches[2], nil
+}
+
+// Given a meet heading on an athlete's page, such as "2023 SCIAC Championships Apr 29, 2023", the meet's name and its
+// result link, return the meet to scrape
+func parseAthleteMeet(heading string, name string, url string) (scrapers.MeetRef, error) {
+	key, err := parseMeetKey(url)
+	if err != nil {
+		return scrapers.MeetRef{}, err
+	}
+	date := athleteMeetDate.FindString(strings.Replace(heading, name, "", 1))
+	start, end, err := parseMeetDates(date)
+	if err != nil {
+		return scrapers.MeetRef{}, err
+	}
+	return scrapers.MeetRef{Key: key, Name: strings.TrimSpace(name), URL: url, Date: start, EndDate: end}, nil
+}
+
+// Given the title, description and link of an item of the rss feed, return the meet it lists. The description holds
+// the meet's dates
+func parseFeedItem(title string, description string, link string) (scrapers.MeetRef, error) {
+	meet := scrapers.MeetRef{Name: strings.TrimSpace(title), URL: strings.TrimSpace(link)}
+	var err error
+	if meet.Key, err = parseMeetKey(meet.URL); err != nil {
+		return scrapers.MeetRef{}, err
+	}
+	if meet.Date, meet.EndDate, err = parseMeetDates(strings.TrimSpace(description)); err != nil {
+		return scrapers.MeetRef{}, fmt.Errorf("unable to parse dates of meet %s: %w", meet.Name, err)
+	}
+	return meet, nil
+}
+
+// Given a row of the tfrrs result search, holding the meet's date and its linked name, return the meet to scrape
+func parseListingRow(row [][]string) (scrapers.MeetRef, error) {
+	var link scrapers.MeetRef
+	found := false
+	for _, cell := range row {
+		if len(cell) > 1 && meetKeyRe.MatchString(cell[1]) && !found {
+			link.Name, link.URL = cell[0], cell[1]
+			link.Key, _ = parseMeetKey(link.URL)
+			found = true
+		} else if start, end, err := parseMeetDates(cell[0]); err == nil {
+			link.Date, link.EndDate = start, end
+		} else if date, err := time.Parse("01/02/06", cell[0]); err == nil {
+			link.Date, link.EndDate = date, date
+		}
+	}
+	if !found {
+		return scrapers.MeetRef{}, fmt.Errorf("no meet link found in listing row %v", row)
+	}
+	if link.Date.IsZero() {
+		return scrapers.MeetRef{}, fmt.Errorf("no date found for meet %s", link.Name)
+	}
+	return link, nil
 }
 
 // Given an xc table header, return whether or not it is a summary
@@ -130,24 +567,146 @@ func parseXCEventType(eventTitle string) (internal.EventType, error) {
 	}
 }
 
-// Given an event title, return the enumerated event
-func parseEvent(eventTitle string) (internal.EventType, error) {
-	sexRe := regexp.MustCompile(`Men's|Women's`)
-	trailingRe := regexp.MustCompile(`(Preliminaries|Finals)?(Heat {\d+})?`)
-	eventParsed := sexRe.ReplaceAllString(eventTitle, "")
-	eventParsed = trailingRe.ReplaceAllString(eventParsed, "")
-	eventParsed = strings.TrimSpace(eventParsed)
+// Removes the kind of an xc table from its title, leaving the race it belongs to
+var xcTableKindRe = regexp.MustCompile(`(team|individual) results`)
+
+var teamScorerHeaderRe = regexp.MustCompile(`^(\d)\*?$`)
+
+// Number of runners whose places count towards a cross country team score
+const xcScorers = 5
+
+func parseTeamTable(header []string, table [][][]string, logger *log.Logger) ([]internal.TeamResult, []string) {
+	ret := make([]internal.TeamResult, 0, len(table))
+	schoolURLs := make([]string, 0, len(table))
+	for _, row := range table {
+		result, schoolURL, err := parseTeamResult(header, row)
+		if err != nil {
+			logger.Printf("Unable to parse team row due to error: %v. Ignoring", err)
+		} else {
+			ret = append(ret, result)
+			schoolURLs = append(schoolURLs, schoolURL)
+		}
+	}
+	return ret, schoolURLs
+}
+
+// Parse a row of a cross country team results table. Columns vary between meets, so they are found by header
+func parseTeamResult(header []string, row [][]string) (result internal.TeamResult, schoolURL string, err error) {
+	for col, name := range header {
+		if col >= len(row) || len(row[col]) == 0 {
+			continue
+		}
+		cell := row[col][0]
+
+		if matches := teamScorerHeaderRe.FindStringSubmatch(name); len(matches) == 2 {
+			// teams that did not field enough runners leave the column blank
+			if len(cell) == 0 {
+				continue
+			}
+			place, err := strconv.Atoi(strings.Trim(cell, "()"))
+			if err != nil {
+				return internal.TeamResult{}, "", fmt.Errorf("scorer %s place could not be parsed: %s", matches[1], cell)
+			}
+			if parseInt64(matches[1]) <= xcScorers {
+				result.Scorers = append(result.Scorers, int64(place))
+			} else {
+				result.Displacers = append(result.Displacers, int64(place))
+			}
+			continue
+		}
+
+		switch name {
+		case "pl", "place":
+			if len(cell) > 0 {
+				result.Place, err = strconv.Atoi(cell)
+			}
+		case "team":
+			if len(row[col]) < 2 {
+				return internal.TeamResult{}, "", errors.New("no school url found for team")
+			}
+			schoolURL = row[col][1]
+		case "score", "points", "pts":
+			result.Score, err = strconv.Atoi(cell)
+		case "total time", "time":
+			result.TotalTime, err = parseTime(cell)
+		case "avg. time", "avg time", "average time", "avg":
+			result.AvgTime, err = parseTime(cell)
+		case "1-5 split", "1-5 spread", "spread", "split":
+			result.Spread, err = parseTime(cell)
+		}
+		if err != nil {
+			return internal.TeamResult{}, "", fmt.Errorf("team column %s could not be parsed: %v", name, err)
+		}
+	}
+
+	if len(schoolURL) == 0 {
+		return internal.TeamResult{}, "", errors.New("no team column found in team results")
+	}
+	return result, schoolURL, nil
+}
+
+var (
+	womenRe = regexp.MustCompile(`(?i)\bwomen['’]?s\b`)
+	menRe   = regexp.MustCompile(`(?i)\bmen['’]?s\b`)
+)
+
+// Parse the sex of an event from its table title, falling back to the sex of the meet page url (/m/ or /f/)
+func parseSex(eventTitle string, url string) int {
+	if womenRe.MatchString(eventTitle) {
+		return internal.WOMEN
+	} else if menRe.MatchString(eventTitle) {
+		return internal.MEN
+	} else if strings.Contains(url, "/f/") {
+		return internal.WOMEN
+	} else if strings.Contains(url, "/m/") {
+		return internal.MEN
+	}
+	return internal.UNKNOWN_SEX
+}
+
+var (
+	sexTitleRe     = regexp.MustCompile(`(?i)\b(wo)?men['’]?s\b`)
+	stageTitleRe   = regexp.MustCompile(`(?i)\b(preliminaries|prelims|semi-?finals|finals?)\b`)
+	heatTitleRe    = regexp.MustCompile(`(?i)\bheat\s+(\d+)`)
+	sectionTitleRe = regexp.MustCompile(`(?i)\bsection\s+(\d+)`)
+)
+
+// Given an event title, return the heat of the enumerated event with its stage, heat number and section
+func parseEvent(eventTitle string) (internal.Heat, error) {
+	heat := internal.Heat{Stage: internal.FINAL}
+	eventParsed := strings.Join(strings.Fields(eventTitle), " ")
+
+	if matches := stageTitleRe.FindStringSubmatch(eventParsed); len(matches) == 2 {
+		switch stage := strings.ToLower(matches[1]); {
+		case strings.HasPrefix(stage, "prelim"):
+			heat.Stage = internal.PRELIM
+		case strings.HasPrefix(stage, "semi"):
+			heat.Stage = internal.SEMIFINAL
+		}
+	}
+	if matches := heatTitleRe.FindStringSubmatch(eventParsed); len(matches) == 2 {
+		heat.Number = int(parseInt64(matches[1]))
+	}
+	if matches := sectionTitleRe.FindStringSubmatch(eventParsed); len(matches) == 2 {
+		heat.Section = int(parseInt64(matches[1]))
+	}
+
+	for _, re := range []*regexp.Regexp{sexTitleRe, stageTitleRe, heatTitleRe, sectionTitleRe} {
+		eventParsed = re.ReplaceAllString(eventParsed, "")
+	}
+	eventParsed = strings.Join(strings.Fields(eventParsed), " ")
 	eventParsed = strings.ToLower(eventParsed)
 
 	event_type, exists := titleToEventEnum[eventParsed]
 	if !exists {
-		return 0, fmt.Errorf("the event title %s, which converts to key %s could not be mapped to an event", eventTitle, eventParsed)
+		return internal.Heat{}, fmt.Errorf("the event title %s, which converts to key %s could not be mapped to an event", eventTitle, eventParsed)
 	}
 
-	return event_type, nil
+	heat.Type = event_type
+	return heat, nil
 }
 
-func parseResultTable(resultTable [][][]string, logger *log.Logger, eventType internal.EventType) ([]internal.Result, []uint32, []string) {
+func parseResultTable(header []string, resultTable [][][]string, logger *log.Logger, eventType internal.EventType) ([]internal.Result, []uint32, []string) {
 	ret := make([]internal.Result, 0, len(resultTable))
 	athleteIDs := make([]uint32, 0, len(resultTable))
 	schoolURLs := make([]string, 0, len(resultTable))
@@ -157,6 +716,12 @@ func parseResultTable(resultTable [][][]string, logger *log.Logger, eventType in
 		if err != nil {
 			logger.Printf("Unable to parse event row due to error: %v. Ignoring", err)
 		} else {
+			// a malformed detail does not invalidate the mark itself, so we keep the row
+			for _, parseDetail := range parseResultDetailClass[eventType] {
+				if err := parseDetail(header, row, &result); err != nil {
+					logger.Printf("Unable to parse result detail due to error: %v. Ignoring detail", err)
+				}
+			}
 			ret = append(ret, result)
 			schoolURLs = append(schoolURLs, schoolURL)
 			athleteIDs = append(athleteIDs, athleteID)
@@ -165,10 +730,17 @@ func parseResultTable(resultTable [][][]string, logger *log.Logger, eventType in
 	return ret, athleteIDs, schoolURLs
 }
 
-func parseXCResult(row [][]string) (result internal.Result, athleteID uint32, schoolURL string, errRet error) {
-	place, err := strconv.Atoi(row[0][0])
-	if err != nil {
-		return internal.Result{}, 0, "", err
+func parseXCResult(row [][]string) (result internal.Result, athleteID uint32, schoolURL string, err error) {
+	if len(row) < 6 {
+		return internal.Result{}, 0, "", fmt.Errorf("xc result row %v is less than the correct length of 6", row)
+	}
+
+	// runners that did not finish are left unplaced
+	if len(row[0][0]) > 0 {
+		result.Place, err = strconv.Atoi(row[0][0])
+		if err != nil {
+			return internal.Result{}, 0, "", err
+		}
 	}
 
 	if len(row[1]) < 2 {
@@ -180,31 +752,27 @@ func parseXCResult(row [][]string) (result internal.Result, athleteID uint32, sc
 		return internal.Result{}, 0, "", err
 	}
 
-	time, err := parseTime(row[5][0])
-	if err != nil {
+	// cross country times are commonly listed to the tenth or second regardless of timing method
+	if err = parseTimedResult(row[5][0], &result, false); err != nil {
 		return internal.Result{}, 0, "", err
 	}
 
-	return internal.Result{
-		Place:    place,
-		Quantity: time,
-	}, athleteID, row[3][1], nil
+	return result, athleteID, row[3][1], nil
 }
 func parseDistanceResult(row [][]string) (result internal.Result, athleteID uint32, schoolURL string, err error) {
 	if len(row) < 5 {
 		return internal.Result{}, 0, "", fmt.Errorf("distance result row %v is less than the correct length of 4", row)
 	}
-	var place int
 
 	if len(row[0][0]) > 0 {
-		place, err = strconv.Atoi(row[0][0])
+		result.Place, err = strconv.Atoi(row[0][0])
 		if err != nil {
 			return internal.Result{}, 0, "", err
 		}
 	}
 
-	time, err := parseTime(row[4][0])
-	if err != nil {
+	// distance times are commonly rounded to the tenth or second from FAT, so only a trailing h marks them hand timed
+	if err = parseTimedResult(row[4][0], &result, false); err != nil {
 		return internal.Result{}, 0, "", err
 	}
 
@@ -213,10 +781,7 @@ func parseDistanceResult(row [][]string) (result internal.Result, athleteID uint
 		return internal.Result{}, 0, "", err
 	}
 
-	return internal.Result{
-		Quantity: time,
-		Place:    place,
-	}, athleteID, row[3][1], nil
+	return result, athleteID, row[3][1], nil
 }
 
 func parseSprintsResult(row [][]string) (result internal.Result, athleteID uint32, schoolURL string, err error) {
@@ -224,29 +789,319 @@ func parseSprintsResult(row [][]string) (result internal.Result, athleteID uint3
 		return internal.Result{}, 0, "", fmt.Errorf("distance result row %v is less than the correct length of 4", row)
 	}
 
-	var place int
+	if len(row[0][0]) > 0 {
+		result.Place, err = strconv.Atoi(row[0][0])
+		if err != nil {
+			return internal.Result{}, 0, "", err
+		}
+	}
+
+	if err = parseTimedResult(row[4][0], &result, true); err != nil {
+		return internal.Result{}, 0, "", err
+	}
+
+	athleteID, err = parseAthleteIDFromURL(row[1][1])
+	if err != nil {
+		return internal.Result{}, 0, "", err
+	}
+
+	return result, athleteID, row[3][1], nil
+}
+
+func parseFieldResult(row [][]string) (result internal.Result, athleteID uint32, schoolURL string, err error) {
+	if len(row) < 5 {
+		return internal.Result{}, 0, "", fmt.Errorf("field result row %v is less than the correct length of 5", row)
+	}
 
 	if len(row[0][0]) > 0 {
-		place, err = strconv.Atoi(row[0][0])
+		result.Place, err = strconv.Atoi(row[0][0])
 		if err != nil {
 			return internal.Result{}, 0, "", err
 		}
 	}
 
-	time, err := parseTime(row[4][0])
+	if len(row[1]) < 2 || len(row[3]) < 2 {
+		return internal.Result{}, 0, "", errors.New("no athlete or school url found")
+	}
+
+	athleteID, err = parseAthleteIDFromURL(row[1][1])
 	if err != nil {
 		return internal.Result{}, 0, "", err
 	}
 
+	// fouls, passes, no-marks and DNS are kept as statuses with no quantity
+	mark := strings.Fields(row[4][0])
+	if len(mark) == 0 {
+		return internal.Result{}, 0, "", fmt.Errorf("no mark found in field result row %v", row)
+	}
+	if status, found := lookupStatus(mark[0]); found {
+		result.Status = status
+	} else {
+		result.Quantity, err = parseMark(row[4][0])
+		if err != nil {
+			return internal.Result{}, 0, "", err
+		}
+	}
+
+	return result, athleteID, row[3][1], nil
+}
+
+var attemptHeaderRe = regexp.MustCompile(`^(?:attempt\s*|#)?(\d)$`)
+
+// Individual attempts that are recorded without a mark
+var attemptStatuses = map[string]int{
+	"X":    internal.FOUL,
+	"F":    internal.FOUL,
+	"FOUL": internal.FOUL,
+	"P":    internal.PASS,
+	"-":    internal.PASS,
+	"PASS": internal.PASS,
+}
+
+// Parse a wind reading in m/s, such as +1.4 or (-0.3)
+func parseWindReading(w string) (float32, error) {
+	w = strings.Trim(strings.TrimSpace(w), "()")
+	wind, err := strconv.ParseFloat(w, 32)
+	if err != nil {
+		return 0.0, fmt.Errorf("wind reading could not be parsed: %s", w)
+	}
+	return float32(wind), nil
+}
+
+var heatWindRe = regexp.MustCompile(`(?i)wind:?\s*\(?([+-]?\d+\.\d+)`)
+
+// Parse the heat wind reading from an event table title, such as Heat 1 Wind: +1.2. Returns nil if none is listed
+func parseHeatWind(title string) *float32 {
+	matches := heatWindRe.FindStringSubmatch(title)
+	if len(matches) != 2 {
+		return nil
+	}
+	wind, err := parseWindReading(matches[1])
+	if err != nil {
+		return nil
+	}
+	return &wind
+}
+
+// Parse an athlete's wind reading from the wind column. Jumps without the column take the wind of the attempt that set
+// the best mark, so the attempt series must be parsed first
+func parseWind(header []string, row [][]string, result *internal.Result) error {
+	if col := slices.Index(header, "wind"); col >= 0 && col < len(row) && len(row[col]) > 0 {
+		// no wind information is left blank or marked NWI
+		if len(row[col][0]) == 0 || strings.EqualFold(row[col][0], "NWI") {
+			return nil
+		}
+		wind, err := parseWindReading(row[col][0])
+		if err != nil {
+			return err
+		}
+		result.WindMS = &wind
+		return nil
+	}
+
+	for _, attempt := range result.Attempts {
+		if attempt.Status == internal.VALID && attempt.Quantity == result.Quantity && attempt.WindMS != nil {
+			result.WindMS = attempt.WindMS
+			return nil
+		}
+	}
+	return nil
+}
+
+// Parse the attempt-by-attempt series of a horizontal jump or throw. Attempt columns are found by their numbered header
+func parseAttempts(header []string, row [][]string, result *internal.Result) error {
+	for col, name := range header {
+		matches := attemptHeaderRe.FindStringSubmatch(name)
+		if len(matches) != 2 || col >= len(row) || len(row[col]) == 0 {
+			continue
+		}
+
+		fields := strings.Fields(row[col][0])
+		// attempts that were not taken are left blank
+		if len(fields) == 0 {
+			continue
+		}
+
+		attempt := internal.Attempt{Number: int(parseInt64(matches[1]))}
+		if status, found := attemptStatuses[strings.ToUpper(fields[0])]; found {
+			attempt.Status = status
+		} else {
+			mark, err := parseMark(row[col][0])
+			if err != nil {
+				return fmt.Errorf("attempt %d could not be parsed: %v", attempt.Number, err)
+			}
+			attempt.Quantity = mark
+		}
+
+		// jumps list the wind reading of each attempt after the mark
+		if len(fields) > 1 {
+			if wind, err := parseWindReading(fields[len(fields)-1]); err == nil {
+				attempt.WindMS = &wind
+			}
+		}
+		result.Attempts = append(result.Attempts, attempt)
+	}
+	return nil
+}
+
+var heightAttemptsRe = regexp.MustCompile(`^[XOP-]+$`)
+
+// Parse the bar-by-bar progression of a high jump or pole vault. Progression columns are headed by the bar height
+func parseHeights(header []string, row [][]string, result *internal.Result) error {
+	for col, name := range header {
+		if col >= len(row) || len(row[col]) == 0 {
+			continue
+		}
+		height, err := parseMark(name)
+		if err != nil {
+			continue
+		}
+
+		// heights the athlete did not reach are left blank
+		attempts := strings.ToUpper(strings.Join(strings.Fields(row[col][0]), ""))
+		if len(attempts) == 0 {
+			continue
+		}
+		if !heightAttemptsRe.MatchString(attempts) {
+			return fmt.Errorf("height progression %s at %.2fm could not be parsed", row[col][0], height)
+		}
+
+		result.Heights = append(result.Heights, internal.HeightAttempt{
+			Height:   height,
+			Attempts: strings.ReplaceAll(attempts, "P", "-"),
+		})
+	}
+	return nil
+}
+
+// Column headers of the disciplines listed in combined event tables
+var headerToComponentEnum = map[string]internal.EventType{
+	"100":          internal.T100M,
+	"100m":         internal.T100M,
+	"100 meters":   internal.T100M,
+	"200":          internal.T200M,
+	"200m":         internal.T200M,
+	"200 meters":   internal.T200M,
+	"400":          internal.T400M,
+	"400m":         internal.T400M,
+	"400 meters":   internal.T400M,
+	"800":          internal.T800M,
+	"800m":         internal.T800M,
+	"800 meters":   internal.T800M,
+	"1500":         internal.T1500M,
+	"1500m":        internal.T1500M,
+	"1500 meters":  internal.T1500M,
+	"60":           internal.T60M,
+	"60m":          internal.T60M,
+	"60 meters":    internal.T60M,
+	"1000":         internal.T1000M,
+	"1000m":        internal.T1000M,
+	"1000 meters":  internal.T1000M,
+	"60h":          internal.T60H,
+	"60 hurdles":   internal.T60H,
+	"60m hurdles":  internal.T60H,
+	"100h":         internal.T100H,
+	"100 hurdles":  internal.T100H,
+	"100m hurdles": internal.T100H,
+	"110h":         internal.T110H,
+	"110 hurdles":  internal.T110H,
+	"110m hurdles": internal.T110H,
+	"hj":           internal.HIGH_JUMP,
+	"high jump":    internal.HIGH_JUMP,
+	"pv":           internal.VAULT,
+	"pole vault":   internal.VAULT,
+	"lj":           internal.LONG_JUMP,
+	"long jump":    internal.LONG_JUMP,
+	"sp":           internal.SHOT,
+	"shot put":     internal.SHOT,
+	"dt":           internal.DISCUS,
+	"discus":       internal.DISCUS,
+	"jt":           internal.JAV,
+	"javelin":      internal.JAV,
+}
+
+func parseMultiResult(row [][]string) (result internal.Result, athleteID uint32, schoolURL string, err error) {
+	if len(row) < 5 {
+		return internal.Result{}, 0, "", fmt.Errorf("combined result row %v is less than the correct length of 5", row)
+	}
+
+	if len(row[0][0]) > 0 {
+		result.Place, err = strconv.Atoi(row[0][0])
+		if err != nil {
+			return internal.Result{}, 0, "", err
+		}
+	}
+
+	if len(row[1]) < 2 || len(row[3]) < 2 {
+		return internal.Result{}, 0, "", errors.New("no athlete or school url found")
+	}
+
 	athleteID, err = parseAthleteIDFromURL(row[1][1])
 	if err != nil {
 		return internal.Result{}, 0, "", err
 	}
 
-	return internal.Result{
-		Quantity: time,
-		Place:    place,
-	}, athleteID, row[3][1], nil
+	// athletes that did not complete every discipline are left without a score
+	if fields := strings.Fields(row[4][0]); len(fields) > 0 {
+		if status, found := lookupStatus(fields[0]); found {
+			result.Status = status
+			return result, athleteID, row[3][1], nil
+		}
+	}
+	points, err := strconv.Atoi(strings.ReplaceAll(row[4][0], ",", ""))
+	if err != nil {
+		return internal.Result{}, 0, "", fmt.Errorf("combined event points could not be parsed: %s", row[4][0])
+	}
+	result.Quantity = float32(points)
+
+	return result, athleteID, row[3][1], nil
+}
+
+// Parse the mark and points of each discipline of a combined event, such as 11.02 (843). The
+// disciplines are found by their column headers, and their points are recomputed from the marks
+func parseComponents(combined internal.EventType) func(header []string, row [][]string, result *internal.Result) error {
+	return func(header []string, row [][]string, result *internal.Result) error {
+		var errs []error
+		for col, name := range header {
+			eventType, found := headerToComponentEnum[name]
+			if !found || col >= len(row) || len(row[col]) == 0 {
+				continue
+			}
+
+			fields := strings.Fields(row[col][0])
+			if len(fields) == 0 {
+				continue
+			}
+
+			component := internal.CombinedComponent{Type: eventType}
+			if points, err := strconv.Atoi(strings.Trim(fields[len(fields)-1], "()")); err == nil && len(fields) > 1 {
+				component.Points = points
+			}
+
+			if status, found := lookupStatus(fields[0]); found {
+				component.Status = status
+			} else {
+				var err error
+				if eventType.IsField() {
+					component.Quantity, err = parseMark(row[col][0])
+				} else {
+					component.Quantity, err = parseTime(fields[0])
+				}
+				if err != nil {
+					errs = append(errs, fmt.Errorf("%s mark could not be parsed: %v", name, err))
+					continue
+				}
+
+				component.ComputedPoints, err = stats.CombinedPoints(combined, eventType, component.Quantity)
+				if err != nil {
+					errs = append(errs, err)
+					continue
+				}
+			}
+			result.Components = append(result.Components, component)
+		}
+		return errors.Join(errs...)
+	}
 }
 
 func parseAthleteIDFromURL(athleteURL string) (uint32, error) {
@@ -261,34 +1116,69 @@ func parseAthleteIDFromURL(athleteURL string) (uint32, error) {
 	return uint32(athleteID), nil
 }
 
-// TODO: parse relay results has not been tested
-func parseRelayResult(row [][]string) (result internal.Result, athleteID uint32, schoolURL string, err error) {
-	if len(row) < 5 {
-		return internal.Result{}, 0, "", fmt.Errorf("relay result row %v is less than the correct length of 5", row)
-	}
+var (
+	relayTeamRe  = regexp.MustCompile(`['"]([A-Z])['"]`)
+	relaySplitRe = regexp.MustCompile(`\(((?:\d+:)?\d+\.\d+)\)`)
+)
+
+// Relay rows list the team, followed by its legs in running order and the team's time
+func parseRelayResult(row [][]string) (internal.Result, uint32, string, error) {
+	return parseRelayRow(row, true)
+}
+
+// Distance relays, like distance races, are only hand timed when marked so
+func parseDistanceRelayResult(row [][]string) (internal.Result, uint32, string, error) {
+	return parseRelayRow(row, false)
+}
 
+func parseRelayRow(row [][]string, inferHand bool) (result internal.Result, athleteID uint32, schoolURL string, err error) {
 	if len(row) < 4 {
-		return internal.Result{}, 0, "", fmt.Errorf("the row was less than length 4")
+		return internal.Result{}, 0, "", fmt.Errorf("relay result row %v is less than the correct length of 4", row)
 	}
 
-	time, err := parseTime(row[3][0])
-	if err != nil {
+	if len(row[0][0]) > 0 {
+		result.Place, err = strconv.Atoi(row[0][0])
+		if err != nil {
+			return internal.Result{}, 0, "", err
+		}
+	}
+
+	if len(row[1]) < 2 {
+		return internal.Result{}, 0, "", errors.New("no school url found for relay")
+	}
+	// schools entering several relays label them 'A', 'B', ...
+	if team := relayTeamRe.FindStringSubmatch(row[1][0]); len(team) == 2 {
+		result.Team = team[1]
+	}
+
+	if err = parseTimedResult(row[3][0], &result, inferHand); err != nil {
 		return internal.Result{}, 0, "", err
 	}
 
-	err = nil
-	members := make([]uint32, 4)
-	for i, m := range strings.Split(row[2][0], ", ") {
-		members[i], err = parseAthleteIDFromURL(m)
+	if len(row[2]) < 2 {
+		return internal.Result{}, 0, "", errors.New("no leg athletes found for relay")
+	}
+	for _, m := range row[2][1:] {
+		member, err := parseAthleteIDFromURL(m)
 		if err != nil {
 			return internal.Result{}, 0, "", err
 		}
+		result.Members = append(result.Members, member)
 	}
 
-	return internal.Result{
-		Quantity: time,
-		Members:  members,
-	}, 0, row[0][2], nil
+	// splits are only kept when every leg has one, otherwise we cannot tell which leg they belong to
+	splits := relaySplitRe.FindAllStringSubmatch(row[2][0], -1)
+	if len(splits) == len(result.Members) {
+		for _, split := range splits {
+			t, err := parseTime(split[1])
+			if err != nil {
+				return internal.Result{}, 0, "", err
+			}
+			result.Splits = append(result.Splits, t)
+		}
+	}
+
+	return result, 0, row[1][1], nil
 }
 
 func notImplementedResult(row [][]string) (internal.Result, uint32, string, error) {
@@ -309,18 +1199,50 @@ var parseIndividualResultClass = map[internal.EventType](func([][]string) (resul
 	internal.T3000M:      parseDistanceResult,
 	internal.T4X100:      parseRelayResult,
 	internal.T4X400:      parseRelayResult,
-	internal.HIGH_JUMP:   notImplementedResult,
-	internal.VAULT:       notImplementedResult,
-	internal.LONG_JUMP:   notImplementedResult,
-	internal.TRIPLE_JUMP: notImplementedResult,
-	internal.SHOT:        notImplementedResult,
-	internal.DISCUS:      notImplementedResult,
-	internal.HAMMER:      notImplementedResult,
-	internal.JAV:         notImplementedResult,
-	internal.DEC:         notImplementedResult,
-	internal.HEPT:        notImplementedResult,
-	internal.T100H:       notImplementedResult,
+	internal.HIGH_JUMP:   parseFieldResult,
+	internal.VAULT:       parseFieldResult,
+	internal.LONG_JUMP:   parseFieldResult,
+	internal.TRIPLE_JUMP: parseFieldResult,
+	internal.SHOT:        parseFieldResult,
+	internal.DISCUS:      parseFieldResult,
+	internal.HAMMER:      parseFieldResult,
+	internal.JAV:         parseFieldResult,
+	internal.DEC:         parseMultiResult,
+	internal.HEPT:        parseMultiResult,
+	internal.T100H:       parseSprintsResult,
 	internal.XC_10K:      parseXCResult,
 	internal.XC_8K:       parseXCResult,
 	internal.XC_6K:       parseXCResult,
+	internal.T60M:        parseSprintsResult,
+	internal.T60H:        parseSprintsResult,
+	internal.T300M:       parseSprintsResult,
+	internal.T500M:       parseDistanceResult,
+	internal.T600M:       parseDistanceResult,
+	internal.T1000M:      parseDistanceResult,
+	internal.MILE:        parseDistanceResult,
+	internal.DMR:         parseDistanceRelayResult,
+	internal.WEIGHT:      parseFieldResult,
+	internal.PENT:        parseMultiResult,
+	internal.HEPT_INDOOR: parseMultiResult,
+}
+
+// Additional per-result information that some events report alongside the row's best mark
+var parseResultDetailClass = map[internal.EventType][]func(header []string, row [][]string, result *internal.Result) error{
+	internal.HIGH_JUMP:   {parseHeights},
+	internal.VAULT:       {parseHeights},
+	internal.T100M:       {parseWind},
+	internal.T200M:       {parseWind},
+	internal.T100H:       {parseWind},
+	internal.T110H:       {parseWind},
+	internal.LONG_JUMP:   {parseAttempts, parseWind},
+	internal.TRIPLE_JUMP: {parseAttempts, parseWind},
+	internal.SHOT:        {parseAttempts},
+	internal.DISCUS:      {parseAttempts},
+	internal.HAMMER:      {parseAttempts},
+	internal.JAV:         {parseAttempts},
+	internal.DEC:         {parseComponents(internal.DEC)},
+	internal.HEPT:        {parseComponents(internal.HEPT)},
+	internal.WEIGHT:      {parseAttempts},
+	internal.PENT:        {parseComponents(internal.PENT)},
+	internal.HEPT_INDOOR: {parseComponents(internal.HEPT_INDOOR)},
 }
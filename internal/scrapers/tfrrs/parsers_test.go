@@ -0,0 +1,718 @@
+package tfrrs
+
+import (
+	"bactic/internal"
+	"math"
+	"testing"
+	"time"
+)
+
+func almostEqual(a, b float32) bool {
+	return math.Abs(float64(a-b)) < 1e-3
+}
+
+func TestParseMark(t *testing.T) {
+	marks := map[string]float32{
+		"7.45m":          7.45,
+		"7.45":           7.45,
+		"16.02m 52' 7\"": 16.02,
+		"6-10.75":        2.1018,
+		"24' 5.25\"":     7.4485,
+		"52-07":          16.0274,
+	}
+	for m, expected := range marks {
+		meters, err := parseMark(m)
+		if err != nil {
+			t.Fatalf("Unexpected error parsing mark %s: %v", m, err)
+		}
+		if !almostEqual(meters, expected) {
+			t.Errorf("Mark %s parsed to %f, expected %f", m, meters, expected)
+		}
+	}
+
+	for _, m := range []string{"", "FOUL", "6-13"} {
+		if _, err := parseMark(m); err == nil {
+			t.Errorf("Expected mark %s to fail parsing", m)
+		}
+	}
+}
+
+func TestParseFieldResult(t *testing.T) {
+	row := [][]string{
+		{"1"},
+		{"Doe, Jane", "https://www.tfrrs.org/athletes/12345/School/Jane_Doe"},
+		{"SR-4"},
+		{"School", "https://www.tfrrs.org/teams/tf/CA_college_f_School"},
+		{"5.82m"},
+	}
+	result, athleteID, schoolURL, err := parseFieldResult(row)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if athleteID != 12345 || schoolURL != row[3][1] {
+		t.Errorf("Unexpected athlete %d or school %s", athleteID, schoolURL)
+	}
+	if result.Place != 1 || result.Status != internal.VALID || !almostEqual(result.Quantity, 5.82) {
+		t.Errorf("Unexpected result %+v", result)
+	}
+
+	statuses := map[string]int{
+		"FOUL": internal.FOUL,
+		"NH":   internal.NO_HEIGHT,
+		"ND":   internal.NO_DISTANCE,
+		"PASS": internal.PASS,
+	}
+	for mark, status := range statuses {
+		row[0][0] = ""
+		row[4][0] = mark
+		result, _, _, err := parseFieldResult(row)
+		if err != nil {
+			t.Fatalf("Expected status %s to be kept but got error %v", mark, err)
+		}
+		if result.Status != status || result.Quantity != 0 {
+			t.Errorf("Mark %s parsed to unexpected result %+v", mark, result)
+		}
+	}
+}
+
+func TestParseAttempts(t *testing.T) {
+	header := []string{"pl", "name", "year", "team", "mark", "1", "2", "3", "4", "5", "6"}
+	row := [][]string{
+		{"1"},
+		{"Doe, Jane", "https://www.tfrrs.org/athletes/12345/School/Jane_Doe"},
+		{"SR-4"},
+		{"School", "https://www.tfrrs.org/teams/tf/CA_college_f_School"},
+		{"6.02m"},
+		{"5.91 +1.2"},
+		{"FOUL"},
+		{"6.02 (-0.4)"},
+		{"PASS"},
+		{"X"},
+		{""},
+	}
+	var result internal.Result
+	if err := parseAttempts(header, row, &result); err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Attempts) != 5 {
+		t.Fatalf("Expected 5 attempts but found %d: %+v", len(result.Attempts), result.Attempts)
+	}
+
+	first := result.Attempts[0]
+	if first.Number != 1 || first.Status != internal.VALID || !almostEqual(first.Quantity, 5.91) {
+		t.Errorf("Unexpected first attempt %+v", first)
+	}
+	if first.WindMS == nil || !almostEqual(*first.WindMS, 1.2) {
+		t.Errorf("Expected a +1.2 wind reading on the first attempt")
+	}
+	if third := result.Attempts[2]; third.WindMS == nil || !almostEqual(*third.WindMS, -0.4) {
+		t.Errorf("Expected a -0.4 wind reading on the third attempt")
+	}
+
+	statuses := []int{internal.VALID, internal.FOUL, internal.VALID, internal.PASS, internal.FOUL}
+	for i, status := range statuses {
+		if result.Attempts[i].Status != status {
+			t.Errorf("Attempt %d had status %d, expected %d", i+1, result.Attempts[i].Status, status)
+		}
+	}
+}
+
+func TestParseHeights(t *testing.T) {
+	header := []string{"pl", "name", "year", "team", "mark", "1.70m", "1.75m", "1.80m", "1.85m"}
+	row := [][]string{
+		{"1"},
+		{"Doe, Jane", "https://www.tfrrs.org/athletes/12345/School/Jane_Doe"},
+		{"SR-4"},
+		{"School", "https://www.tfrrs.org/teams/tf/CA_college_f_School"},
+		{"1.80m"},
+		{"P"},
+		{"o"},
+		{"X X O"},
+		{"XXX"},
+	}
+	var result internal.Result
+	if err := parseHeights(header, row, &result); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []internal.HeightAttempt{
+		{Height: 1.70, Attempts: "-"},
+		{Height: 1.75, Attempts: "O"},
+		{Height: 1.80, Attempts: "XXO"},
+		{Height: 1.85, Attempts: "XXX"},
+	}
+	if len(result.Heights) != len(expected) {
+		t.Fatalf("Expected %d heights but found %+v", len(expected), result.Heights)
+	}
+	for i, h := range expected {
+		if !almostEqual(result.Heights[i].Height, h.Height) || result.Heights[i].Attempts != h.Attempts {
+			t.Errorf("Height %d parsed to %+v, expected %+v", i, result.Heights[i], h)
+		}
+	}
+
+	row[6][0] = "1.75"
+	if err := parseHeights(header, row, &internal.Result{}); err == nil {
+		t.Error("Expected a malformed progression to fail parsing")
+	}
+}
+
+func TestParseComponents(t *testing.T) {
+	header := []string{"pl", "name", "year", "team", "points", "100m", "lj", "sp", "hj", "400m"}
+	row := [][]string{
+		{"1"},
+		{"Doe, John", "https://www.tfrrs.org/athletes/12345/School/John_Doe"},
+		{"SR-4"},
+		{"School", "https://www.tfrrs.org/teams/tf/CA_college_m_School"},
+		{"3,901"},
+		{"10.90 (883)"},
+		{"7.12m (842)"},
+		{"FOUL (0)"},
+		{"1.95 (758)"},
+		{""},
+	}
+	result, _, _, err := parseMultiResult(row)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Quantity != 3901 {
+		t.Errorf("Expected 3901 points but parsed %f", result.Quantity)
+	}
+
+	if err := parseComponents(internal.DEC)(header, row, &result); err != nil {
+		t.Fatal(err)
+	}
+	expected := []internal.CombinedComponent{
+		{Type: internal.T100M, Quantity: 10.90, Points: 883, ComputedPoints: 883},
+		{Type: internal.LONG_JUMP, Quantity: 7.12, Points: 842, ComputedPoints: 842},
+		{Type: internal.SHOT, Status: internal.FOUL},
+		{Type: internal.HIGH_JUMP, Quantity: 1.95, Points: 758, ComputedPoints: 758},
+	}
+	if len(result.Components) != len(expected) {
+		t.Fatalf("Expected %d components but found %+v", len(expected), result.Components)
+	}
+	for i, c := range expected {
+		r := result.Components[i]
+		if r.Type != c.Type || r.Status != c.Status || r.Points != c.Points || r.ComputedPoints != c.ComputedPoints || !almostEqual(r.Quantity, c.Quantity) {
+			t.Errorf("Component %d parsed to %+v, expected %+v", i, r, c)
+		}
+	}
+}
+
+func TestParseRelayResult(t *testing.T) {
+	row := [][]string{
+		{"2"},
+		{"School 'B'", "https://www.tfrrs.org/teams/tf/CA_college_m_School"},
+		{"1. A Doe (49.10) 2. B Doe (48.21) 3. C Doe (48.90) 4. D Doe (47.55)",
+			"https://www.tfrrs.org/athletes/1/School/A_Doe",
+			"https://www.tfrrs.org/athletes/2/School/B_Doe",
+			"https://www.tfrrs.org/athletes/3/School/C_Doe",
+			"https://www.tfrrs.org/athletes/4/School/D_Doe",
+		},
+		{"3:13.76"},
+	}
+	result, athleteID, schoolURL, err := parseRelayResult(row)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if athleteID != 0 || schoolURL != row[1][1] {
+		t.Errorf("Expected no athlete and the team's school, got %d and %s", athleteID, schoolURL)
+	}
+	if result.Place != 2 || result.Team != "B" || !almostEqual(result.Quantity, 193.76) {
+		t.Errorf("Unexpected relay result %+v", result)
+	}
+	for i, m := range result.Members {
+		if m != uint32(i+1) {
+			t.Errorf("Expected leg %d to be athlete %d but got %d", i+1, i+1, m)
+		}
+	}
+	if result.Timing != internal.FAT {
+		t.Errorf("Expected a FAT time but got timing %d", result.Timing)
+	}
+
+	// a sprint relay read to the tenth is hand timed, while a distance relay is not
+	handRow := append([][]string{}, row...)
+	handRow[3] = []string{"3:13.7"}
+	if relay, _, _, err := parseRelayResult(handRow); err != nil || relay.Timing != internal.HAND {
+		t.Errorf("Expected a hand timed sprint relay but got %+v, %v", relay, err)
+	}
+	if relay, _, _, err := parseDistanceRelayResult(handRow); err != nil || relay.Timing != internal.FAT {
+		t.Errorf("Expected a FAT distance relay but got %+v, %v", relay, err)
+	}
+
+	splits := []float32{49.10, 48.21, 48.90, 47.55}
+	if len(result.Splits) != len(splits) {
+		t.Fatalf("Expected %d splits but got %v", len(splits), result.Splits)
+	}
+	for i, split := range splits {
+		if !almostEqual(result.Splits[i], split) {
+			t.Errorf("Expected leg %d split %f but got %f", i+1, split, result.Splits[i])
+		}
+	}
+}
+
+func TestParseTeamResult(t *testing.T) {
+	header := []string{"pl", "team", "score", "1", "2", "3", "4", "5", "6*", "7*", "total time", "avg. time"}
+	row := [][]string{
+		{"1"},
+		{"School", "https://www.tfrrs.org/teams/xc/CA_college_m_School"},
+		{"38"},
+		{"2"}, {"5"}, {"8"}, {"10"}, {"13"}, {"(17)"}, {""},
+		{"2:06:10.50"},
+		{"25:14.10"},
+	}
+	result, schoolURL, err := parseTeamResult(header, row)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if schoolURL != row[1][1] {
+		t.Errorf("Unexpected school url %s", schoolURL)
+	}
+	if result.Place != 1 || result.Score != 38 || len(result.Scorers) != 5 || result.Scorers[4] != 13 {
+		t.Errorf("Unexpected team result %+v", result)
+	}
+	if len(result.Displacers) != 1 || result.Displacers[0] != 17 {
+		t.Errorf("Expected a single displacer in 17th but got %v", result.Displacers)
+	}
+	if !almostEqual(result.AvgTime, 25*60+14.10) {
+		t.Errorf("Unexpected average time %f", result.AvgTime)
+	}
+
+	if _, _, err := parseTeamResult(header[:1], row[:1]); err == nil {
+		t.Error("Expected a team row without a team column to fail parsing")
+	}
+}
+
+func TestParseSex(t *testing.T) {
+	cases := []struct {
+		title, url string
+		sex        int
+	}{
+		{"Women's 5000 Meters", "https://tfrrs.org/results/79700/f/2023_SCIAC_TF_Championships", internal.WOMEN},
+		{"Men's 5000 Meters", "https://tfrrs.org/results/79700/m/2023_SCIAC_TF_Championships", internal.MEN},
+		{"men's 8k individual results", "https://tfrrs.org/results/xc/23218/2023_SCIAC_Cross_Country_Championships", internal.MEN},
+		{"5000 Meters", "https://tfrrs.org/results/79700/f/2023_SCIAC_TF_Championships", internal.WOMEN},
+		{"5000 Meters", "https://tfrrs.org/results/xc/23218/2023_SCIAC_Cross_Country_Championships", internal.UNKNOWN_SEX},
+	}
+	for _, c := range cases {
+		if sex := parseSex(c.title, c.url); sex != c.sex {
+			t.Errorf("Title %s at %s parsed to sex %d, expected %d", c.title, c.url, sex, c.sex)
+		}
+	}
+}
+
+func TestParseEvent(t *testing.T) {
+	cases := map[string]internal.Heat{
+		"Men's 100 Meters":                         {Type: internal.T100M, Stage: internal.FINAL},
+		"Men's 100 Meters Preliminaries Heat 2":    {Type: internal.T100M, Stage: internal.PRELIM, Number: 2},
+		"Women's 1500 Meters\n   Finals Section 3": {Type: internal.T1500M, Stage: internal.FINAL, Section: 3},
+		"Women's 400 Hurdles Semi-Finals Heat 1":   {Type: internal.T400H, Stage: internal.SEMIFINAL, Number: 1},
+		"Women’s Long Jump Finals":                 {Type: internal.LONG_JUMP, Stage: internal.FINAL},
+	}
+	for title, expected := range cases {
+		heat, err := parseEvent(title)
+		if err != nil {
+			t.Errorf("Unexpected error parsing title %s: %v", title, err)
+			continue
+		}
+		if heat != expected {
+			t.Errorf("Title %s parsed to %+v, expected %+v", title, heat, expected)
+		}
+	}
+
+	if _, err := parseEvent("Men's Team Scores"); err == nil {
+		t.Error("Expected an unknown event title to fail parsing")
+	}
+}
+
+func TestParseWind(t *testing.T) {
+	if wind := parseHeatWind("Men's 100 Meters Finals Wind: +2.4"); wind == nil || !almostEqual(*wind, 2.4) {
+		t.Errorf("Expected a heat wind of +2.4")
+	}
+	if wind := parseHeatWind("Men's 100 Meters Finals"); wind != nil {
+		t.Errorf("Expected no heat wind but got %f", *wind)
+	}
+
+	header := []string{"pl", "name", "year", "team", "time", "wind"}
+	row := [][]string{{"1"}, {"Doe, John"}, {"SR-4"}, {"School"}, {"10.21"}, {"-1.1"}}
+	var result internal.Result
+	if err := parseWind(header, row, &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.WindMS == nil || !almostEqual(*result.WindMS, -1.1) || result.WindAided() {
+		t.Errorf("Expected a legal wind of -1.1")
+	}
+
+	// jumps take the wind of their best attempt
+	aided := float32(2.3)
+	result = internal.Result{
+		Quantity: 7.45,
+		Attempts: []internal.Attempt{{Number: 1, Quantity: 7.45, WindMS: &aided}},
+	}
+	if err := parseWind(header[:5], row[:5], &result); err != nil {
+		t.Fatal(err)
+	}
+	if !result.WindAided() {
+		t.Errorf("Expected the best attempt's +2.3 wind to make the mark aided")
+	}
+}
+
+func TestParseTimedResult(t *testing.T) {
+	cases := []struct {
+		time     string
+		quantity float32
+		status   int
+		dqCode   string
+	}{
+		{"4:05.12", 245.12, internal.VALID, ""},
+		{"DNF", 0, internal.DNF, ""},
+		{"DNS", 0, internal.DNS, ""},
+		{"DQ", 0, internal.DQ, ""},
+		{"DQ 163-3a", 0, internal.DQ, "163-3a"},
+		{"DQ (168-7b)", 0, internal.DQ, "168-7b"},
+		{"FS", 0, internal.FS, ""},
+		{"NT", 0, internal.NT, ""},
+	}
+	for _, c := range cases {
+		var result internal.Result
+		if err := parseTimedResult(c.time, &result, true); err != nil {
+			t.Fatalf("Unable to parse %s: %v", c.time, err)
+		}
+		if !almostEqual(result.Quantity, c.quantity) || result.Status != c.status || result.DQCode != c.dqCode {
+			t.Errorf("Expected %s to parse to %f with status %d and code %q but got %+v", c.time, c.quantity, c.status, c.dqCode, result)
+		}
+	}
+
+	row := [][]string{
+		{""},
+		{"Doe, Jane", "https://www.tfrrs.org/athletes/7/School/Jane_Doe"},
+		{"SO-2"},
+		{"School", "https://www.tfrrs.org/teams/tf/CA_college_f_School"},
+		{"DNF"},
+	}
+	result, athleteID, _, err := parseDistanceResult(row)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if athleteID != 7 || result.Place != 0 || result.Status != internal.DNF {
+		t.Errorf("Expected an unplaced DNF for athlete 7 but got %+v", result)
+	}
+
+	// distance times rounded to the tenth are not taken as hand timed
+	row[4] = []string{"14:32.1"}
+	if result, _, _, err = parseDistanceResult(row); err != nil || result.Timing != internal.FAT {
+		t.Errorf("Expected a FAT time but got %+v, %v", result, err)
+	}
+	row[4] = []string{"14:32.1h"}
+	if result, _, _, err = parseDistanceResult(row); err != nil || result.Timing != internal.HAND {
+		t.Errorf("Expected a marked hand time but got %+v, %v", result, err)
+	}
+}
+
+func TestParseTimeMethod(t *testing.T) {
+	cases := []struct {
+		time    string
+		seconds float32
+		timing  int
+	}{
+		{"10.85", 10.85, internal.FAT},
+		{"10.8", 10.8, internal.HAND},
+		{"10.8h", 10.8, internal.HAND},
+		{"48.2 h", 48.2, internal.HAND},
+		{"52", 52, internal.HAND},
+		{"4:05.12", 245.12, internal.FAT},
+		{"4:05.1", 245.1, internal.HAND},
+		{"0:45", 45, internal.HAND},
+		{"1:02:03.45", 3723.45, internal.FAT},
+		{"10.85 (10.842)", 10.85, internal.FAT},
+	}
+	for _, c := range cases {
+		seconds, timing, err := parseTimeMethod(c.time, true)
+		if err != nil {
+			t.Fatalf("Unable to parse %s: %v", c.time, err)
+		}
+		if !almostEqual(seconds, c.seconds) || timing != c.timing {
+			t.Errorf("Expected %s to parse to %f with timing %d but got %f with timing %d", c.time, c.seconds, c.timing, seconds, timing)
+		}
+	}
+
+	// cross country times are not inferred to be hand timed
+	if _, timing, _ := parseTimeMethod("24:31.2", false); timing != internal.FAT {
+		t.Errorf("Expected a FAT time without inference but got timing %d", timing)
+	}
+	if _, _, err := parseTimeMethod("abc", true); err == nil {
+		t.Errorf("Expected an error parsing an invalid time")
+	}
+}
+
+func TestParseIndoorEvent(t *testing.T) {
+	cases := map[string]internal.EventType{
+		"Women's 60 Meters Prelims":     internal.T60M,
+		"Men's 60 Hurdles Final":        internal.T60H,
+		"Men's Mile":                    internal.MILE,
+		"Women's Distance Medley Relay": internal.DMR,
+		"Men's Weight Throw":            internal.WEIGHT,
+		"Women's Pentathlon":            internal.PENT,
+	}
+	for title, eventType := range cases {
+		heat, err := parseEvent(title)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if heat.Type != eventType {
+			t.Errorf("Expected %s to be event %d but got %d", title, eventType, heat.Type)
+		}
+	}
+
+	tracks := map[string]int{
+		"Boston, MA | Reggie Lewis Center (Banked)": internal.BANKED_TRACK,
+		"Flat Track":           internal.FLAT_TRACK,
+		"300m Oversized Track": internal.OVERSIZED_TRACK,
+		"Claremont, CA":        internal.UNKNOWN_TRACK,
+	}
+	for header, trackType := range tracks {
+		if parsed := parseTrackType(header); parsed != trackType {
+			t.Errorf("Expected %s to be track type %d but got %d", header, trackType, parsed)
+		}
+	}
+}
+
+func TestParseSeason(t *testing.T) {
+	cases := []struct {
+		link   string
+		date   time.Time
+		events []internal.EventType
+		season int
+	}{
+		{"https://www.tfrrs.org/results/xc/12345/Fall_Classic", time.Date(2023, time.October, 7, 0, 0, 0, 0, time.UTC), nil, internal.XC},
+		{"https://www.tfrrs.org/results/12345/Winter_Open", time.Date(2023, time.January, 20, 0, 0, 0, 0, time.UTC), nil, internal.INDOOR},
+		{"https://www.tfrrs.org/results/12345/Spring_Open", time.Date(2023, time.April, 1, 0, 0, 0, 0, time.UTC), nil, internal.OUTDOOR},
+		// a fall track meet is not cross country
+		{"https://www.tfrrs.org/results/12345/Early_Open", time.Date(2023, time.November, 30, 0, 0, 0, 0, time.UTC), nil, internal.INDOOR},
+		// events outweigh the date
+		{"https://www.tfrrs.org/results/12345/Late_Indoor", time.Date(2023, time.March, 20, 0, 0, 0, 0, time.UTC),
+			[]internal.EventType{internal.T60M, internal.MILE, internal.T400M}, internal.INDOOR},
+		{"https://www.tfrrs.org/results/12345/Early_Outdoor", time.Date(2023, time.March, 4, 0, 0, 0, 0, time.UTC),
+			[]internal.EventType{internal.T100M, internal.T3000S}, internal.OUTDOOR},
+	}
+	for _, c := range cases {
+		if season := parseSeason(c.link, c.date, c.events); season != c.season {
+			t.Errorf("Expected %s to be season %d but got %d", c.link, c.season, season)
+		}
+	}
+}
+
+func TestParseMeetDates(t *testing.T) {
+	cases := []struct {
+		date  string
+		start time.Time
+		end   time.Time
+	}{
+		{"April 29, 2023", time.Date(2023, time.April, 29, 0, 0, 0, 0, time.UTC), time.Date(2023, time.April, 29, 0, 0, 0, 0, time.UTC)},
+		{"May 5-6, 2023", time.Date(2023, time.May, 5, 0, 0, 0, 0, time.UTC), time.Date(2023, time.May, 6, 0, 0, 0, 0, time.UTC)},
+		{"Feb 28-Mar 1, 2024", time.Date(2024, time.February, 28, 0, 0, 0, 0, time.UTC), time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)},
+		{"December 30, 2023 - January 2, 2024", time.Date(2023, time.December, 30, 0, 0, 0, 0, time.UTC), time.Date(2024, time.January, 2, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, c := range cases {
+		start, end, err := parseMeetDates(c.date)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !start.Equal(c.start) || !end.Equal(c.end) {
+			t.Errorf("Expected %s to run from %v to %v but got %v to %v", c.date, c.start, c.end, start, end)
+		}
+	}
+	if _, _, err := parseMeetDates("TBD"); err == nil {
+		t.Error("Expected an error parsing an unlisted date")
+	}
+}
+
+func TestParseMeetHeader(t *testing.T) {
+	items := [][]string{
+		{"February 10-11, 2023"},
+		{"Boston, MA | Reggie Lewis Center (Banked)"},
+		{"Hosted by: Tufts", "https://www.tfrrs.org/teams/tf/MA_college_m_Tufts.html"},
+		{"Track Size: 200m"},
+		{"Track Surface: Mondo"},
+		{"Timing: Bay State Timing"},
+	}
+	var meet internal.Meet
+	hostURL := parseMeetHeader(items, &meet)
+	if hostURL != items[2][1] {
+		t.Errorf("Expected the host link %s but got %s", items[2][1], hostURL)
+	}
+	expected := internal.Meet{
+		Date:          time.Date(2023, time.February, 10, 0, 0, 0, 0, time.UTC),
+		EndDate:       time.Date(2023, time.February, 11, 0, 0, 0, 0, time.UTC),
+		Venue:         "Reggie Lewis Center",
+		City:          "Boston",
+		State:         "MA",
+		Host:          "Tufts",
+		Surface:       "Mondo",
+		TrackSize:     200,
+		TrackType:     internal.BANKED_TRACK,
+		TimingCompany: "Bay State Timing",
+	}
+	if meet != expected {
+		t.Errorf("Expected meet details %+v but got %+v", expected, meet)
+	}
+
+	meet = internal.Meet{}
+	parseMeetHeader([][]string{{"Pomona-Pitzer Track, Claremont, CA"}}, &meet)
+	if meet.Venue != "Pomona-Pitzer Track" || meet.City != "Claremont" || meet.State != "CA" {
+		t.Errorf("Unexpected meet location %+v", meet)
+	}
+}
+
+func TestParseHeatDate(t *testing.T) {
+	meet := internal.Meet{
+		Date:    time.Date(2023, time.May, 25, 0, 0, 0, 0, time.UTC),
+		EndDate: time.Date(2023, time.May, 27, 0, 0, 0, 0, time.UTC),
+	}
+	cases := map[string]time.Time{
+		"Men's 1500 Meters Prelims Thursday, May 25": time.Date(2023, time.May, 25, 0, 0, 0, 0, time.UTC),
+		"Women's 10,000 Meters Final Sat. May 27":    time.Date(2023, time.May, 27, 0, 0, 0, 0, time.UTC),
+		"Men's Decathlon May 26, 2023":               time.Date(2023, time.May, 26, 0, 0, 0, 0, time.UTC),
+		"Women's 4 x 400 Relay Final Sat., May 27":   time.Date(2023, time.May, 27, 0, 0, 0, 0, time.UTC),
+	}
+	for title, expected := range cases {
+		date, found := parseHeatDate(title, meet)
+		if !found || !date.Equal(expected) {
+			t.Errorf("Expected %s to be run on %v but got %v", title, expected, date)
+		}
+	}
+	if _, found := parseHeatDate("Men's 400 Meters Heat 2", meet); found {
+		t.Error("Expected no date in a title without one")
+	}
+
+	// meets over the new year take the following year for later days
+	newYear := internal.Meet{
+		Date:    time.Date(2023, time.December, 30, 0, 0, 0, 0, time.UTC),
+		EndDate: time.Date(2024, time.January, 2, 0, 0, 0, 0, time.UTC),
+	}
+	if date, _ := parseHeatDate("Men's Mile Jan 2", newYear); !date.Equal(newYear.EndDate) {
+		t.Errorf("Expected the mile to be run on %v but got %v", newYear.EndDate, date)
+	}
+}
+
+func TestParseClassYear(t *testing.T) {
+	cases := []struct {
+		class       string
+		year        int
+		eligibility int
+		redshirt    bool
+	}{
+		{"FR-1", internal.FRESHMAN, 1, false},
+		{"SO-2", internal.SOPHOMORE, 2, false},
+		{"RS-JR", internal.JUNIOR, 0, true},
+		{"SR (RS)", internal.SENIOR, 0, true},
+		{"5th-5", internal.FIFTH_YEAR, 5, false},
+		{"GR", internal.GRADUATE, 0, false},
+	}
+	for _, c := range cases {
+		var athlete internal.Athlete
+		if !parseClassYear(c.class, &athlete) {
+			t.Fatalf("Unable to parse class year %s", c.class)
+		}
+		if athlete.Year != c.year || athlete.Eligibility != c.eligibility || athlete.Redshirt != c.redshirt {
+			t.Errorf("Expected %s to parse to class %d, eligibility %d and redshirt %t but got %+v", c.class, c.year, c.eligibility, c.redshirt, athlete)
+		}
+	}
+	var athlete internal.Athlete
+	if parseClassYear("Sprints", &athlete) {
+		t.Error("Expected an event group to not be a class year")
+	}
+}
+
+func TestParseAthleteProfile(t *testing.T) {
+	athlete := internal.Athlete{ID: 1}
+	parseAthleteTitle([]string{"JANE DOE", "  SO-2  ", "Hometown: Portland, OR"}, &athlete)
+	if athlete.Name != "Jane Doe" || athlete.Year != internal.SOPHOMORE || athlete.Hometown != "Portland, OR" {
+		t.Errorf("Unexpected athlete profile %+v", athlete)
+	}
+
+	headings := []string{"2024 Indoor", "Meet Date", "2023 Outdoor", "2023 Indoor", "2023 Cross Country", "2022 Outdoor", "2023 Outdoor"}
+	rosters := parseRosters(headings, athlete, 7)
+	expected := []internal.Roster{
+		{AthleteID: 1, SchoolID: 7, Year: 2024, Season: internal.INDOOR, ClassYear: internal.SOPHOMORE},
+		{AthleteID: 1, SchoolID: 7, Year: 2023, Season: internal.OUTDOOR, ClassYear: internal.FRESHMAN},
+		{AthleteID: 1, SchoolID: 7, Year: 2023, Season: internal.INDOOR, ClassYear: internal.FRESHMAN},
+		{AthleteID: 1, SchoolID: 7, Year: 2023, Season: internal.XC, ClassYear: internal.SOPHOMORE},
+		{AthleteID: 1, SchoolID: 7, Year: 2022, Season: internal.OUTDOOR, ClassYear: internal.UNKNOWN_CLASS},
+	}
+	if len(rosters) != len(expected) {
+		t.Fatalf("Expected %d rosters but got %v", len(expected), rosters)
+	}
+	for i := range expected {
+		if rosters[i] != expected[i] {
+			t.Errorf("Expected roster %+v but got %+v", expected[i], rosters[i])
+		}
+	}
+}
+
+func TestParseAthleteMeet(t *testing.T) {
+	keys := map[string]string{
+		"https://www.tfrrs.org/results/79700/m/2023_SCIAC_TF_Championships":             "tfrrs/tf/79700",
+		"https://www.tfrrs.org/results/79700/4122/2023_SCIAC_TF_Championships/Mens_800": "tfrrs/tf/79700",
+		"https://tfrrs.org/results/xc/23218/2023_SCIAC_Cross_Country_Championships":     "tfrrs/xc/23218",
+	}
+	for url, expected := range keys {
+		key, err := parseMeetKey(url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if key != expected {
+			t.Errorf("Expected %s to have key %s but got %s", url, expected, key)
+		}
+	}
+	if _, err := parseMeetKey("https://www.tfrrs.org/athletes/7"); err == nil {
+		t.Error("Expected an athlete link to not identify a meet")
+	}
+
+	url := "https://www.tfrrs.org/results/79700/2023_SCIAC_TF_Championships"
+	meet, err := parseAthleteMeet("2023 SCIAC TF Championships\n  Apr 28-29, 2023", "2023 SCIAC TF Championships", url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start, end := time.Date(2023, time.April, 28, 0, 0, 0, 0, time.UTC), time.Date(2023, time.April, 29, 0, 0, 0, 0, time.UTC)
+	if meet.Name != "2023 SCIAC TF Championships" || meet.URL != url || !meet.Date.Equal(start) || !meet.EndDate.Equal(end) {
+		t.Errorf("Unexpected meet %+v", meet)
+	}
+	if _, err := parseAthleteMeet("2:05.31", "2:05.31", url); err == nil {
+		t.Error("Expected an error for a link without a meet date")
+	}
+}
+
+func TestParseListingRow(t *testing.T) {
+	url := "https://www.tfrrs.org/results/xc/23218/2023_SCIAC_Cross_Country_Championships"
+	link, err := parseListingRow([][]string{
+		{"10/28/23"},
+		{"2023 SCIAC Cross Country Championships", url},
+		{"Pomona-Pitzer", "https://www.tfrrs.org/teams/xc/CA_college_m_Pomona_Pitzer.html"},
+		{"CA"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	date := time.Date(2023, time.October, 28, 0, 0, 0, 0, time.UTC)
+	if link.Name != "2023 SCIAC Cross Country Championships" || link.URL != url || !link.Date.Equal(date) || !link.EndDate.Equal(date) {
+		t.Errorf("Unexpected meet %+v", link)
+	}
+
+	link, err = parseListingRow([][]string{
+		{"April 28-29, 2023"},
+		{"2023 SCIAC TF Championships", "https://www.tfrrs.org/results/79700/2023_SCIAC_TF_Championships"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !link.EndDate.Equal(time.Date(2023, time.April, 29, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected the meet to end on April 29 but got %v", link.EndDate)
+	}
+
+	if _, err = parseListingRow([][]string{{"10/28/23"}, {"Pomona-Pitzer", "https://www.tfrrs.org/teams/xc/CA_college_m_Pomona_Pitzer.html"}}); err == nil {
+		t.Error("Expected an error for a row without a meet link")
+	}
+	if _, err = parseListingRow([][]string{{"2023 SCIAC TF Championships", "https://www.tfrrs.org/results/79700"}}); err == nil {
+		t.Error("Expected an error for a row without a date")
+	}
+}
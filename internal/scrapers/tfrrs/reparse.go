@@ -0,0 +1,89 @@
+package tfrrs
+
+import (
+	"bactic/internal/scrapers"
+	"bactic/internal/scrapers/fetch"
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Archived meets to parse again, every archived meet if both are empty
+type ReparseOptions struct {
+	// Meet IDs as they appear in result links, such as 79700 for a track meet or xc/23218 for cross country
+	MeetIDs []string
+	// Only meets whose page contains this text, such as the title of an event given a new parser
+	Match string
+}
+
+// Parse archived meet pages again without touching the network, updating the meets already stored. The config's
+// transport must replay from an archive
+func Reparse(db *sql.DB, ctx context.Context, options ReparseOptions, config Config) error {
+	config.Transport = config.transport()
+	archive := config.Transport.Archive()
+	if archive == nil {
+		return errors.New("reparsing needs an archive to replay from")
+	}
+	records, err := archive.Records()
+	if err != nil {
+		return err
+	}
+	links, err := archivedMeets(records, options, archive.Body)
+	if err != nil {
+		return err
+	}
+
+	logger := log.New(os.Stdout, "Reparse ", log.Ldate|log.Ltime)
+	logger.Printf("Parsing %d archived meets", len(links))
+	// only the archived meets are parsed, so the meets found on athlete pages are not followed
+	config.Backfill = false
+	scrapers.ScrapeAll(db, ctx, NewSource(config), links, true, logger)
+	return nil
+}
+
+// Latest archived page of each meet selected by the options, in the order the meets were first fetched
+func archivedMeets(records []fetch.Record, options ReparseOptions, body func(digest string) ([]byte, error)) ([]scrapers.MeetRef, error) {
+	selected := make(map[string]bool)
+	for _, id := range options.MeetIDs {
+		key, err := parseMeetKey("/results/" + strings.Trim(id, "/"))
+		if err != nil {
+			return nil, err
+		}
+		selected[key] = true
+	}
+
+	var keys []string
+	latest := make(map[string]fetch.Record)
+	for _, record := range records {
+		key, err := parseMeetKey(record.URL)
+		if err != nil || record.Status != http.StatusOK || (len(selected) > 0 && !selected[key]) {
+			continue
+		}
+		if _, found := latest[key]; !found {
+			keys = append(keys, key)
+		}
+		latest[key] = record
+	}
+
+	match := []byte(strings.ToLower(options.Match))
+	var links []scrapers.MeetRef
+	for _, key := range keys {
+		record := latest[key]
+		if len(match) > 0 {
+			page, err := body(record.Digest)
+			if err != nil {
+				return nil, err
+			}
+			if !bytes.Contains(bytes.ToLower(page), match) {
+				continue
+			}
+		}
+		links = append(links, scrapers.MeetRef{Key: key, URL: record.URL})
+	}
+	return links, nil
+}
@@ -0,0 +1,56 @@
+package tfrrs
+
+import (
+	"bactic/internal/scrapers/fetch"
+	"net/http"
+	"testing"
+)
+
+func TestArchivedMeets(t *testing.T) {
+	bodies := map[string]string{
+		"a": "<h3>Weight Throw</h3>",
+		"b": "<h3>Shot Put</h3>",
+		"c": "<h3>Men's 8k</h3>",
+	}
+	body := func(digest string) ([]byte, error) {
+		return []byte(bodies[digest]), nil
+	}
+	records := []fetch.Record{
+		{URL: "https://www.tfrrs.org/results.rss", Status: http.StatusOK, Digest: "c"},
+		{URL: "https://www.tfrrs.org/results/79700/2023_SCIAC_TF_Championships", Status: http.StatusOK, Digest: "a"},
+		{URL: "https://www.tfrrs.org/athletes/7", Status: http.StatusOK, Digest: "b"},
+		{URL: "https://www.tfrrs.org/results/xc/23218/2023_SCIAC_Cross_Country_Championships", Status: http.StatusOK, Digest: "c"},
+		{URL: "https://www.tfrrs.org/results/80000/Bactic_Invitational", Status: http.StatusServiceUnavailable, Digest: "a"},
+		// the meet was fetched again after results were posted
+		{URL: "https://www.tfrrs.org/results/79700/2023_SCIAC_TF_Championships", Status: http.StatusOK, Digest: "b"},
+	}
+
+	links, err := archivedMeets(records, ReparseOptions{}, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(links) != 2 || links[0].URL != records[1].URL || links[1].URL != records[3].URL {
+		t.Errorf("Expected the archived track and cross country meets but got %+v", links)
+	}
+
+	if links, err = archivedMeets(records, ReparseOptions{MeetIDs: []string{"xc/23218"}}, body); err != nil {
+		t.Fatal(err)
+	}
+	if len(links) != 1 || links[0].URL != records[3].URL {
+		t.Errorf("Expected only the cross country meet but got %+v", links)
+	}
+
+	// only the latest fetch of a meet is matched
+	if links, err = archivedMeets(records, ReparseOptions{Match: "weight throw"}, body); err != nil {
+		t.Fatal(err)
+	}
+	if len(links) != 0 {
+		t.Errorf("Expected no meets to match but got %+v", links)
+	}
+	if links, err = archivedMeets(records, ReparseOptions{Match: "shot put"}, body); err != nil {
+		t.Fatal(err)
+	}
+	if len(links) != 1 || links[0].URL != records[1].URL {
+		t.Errorf("Expected the track meet to match but got %+v", links)
+	}
+}
@@ -0,0 +1,183 @@
+package tfrrs
+
+import (
+	"bactic/internal"
+	"bactic/internal/database"
+	"bactic/internal/scrapers"
+	"bactic/internal/scrapers/fetch"
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/antchfx/xmlquery"
+	"github.com/gocolly/colly"
+)
+
+const feedURL = "https://www.tfrrs.org/results.rss"
+
+func init() {
+	scrapers.Register("tfrrs", func(config scrapers.Config) (scrapers.Source, error) {
+		return NewSource(Config{
+			Backfill:  config.Backfill,
+			Refresh:   config.Refresh,
+			Transport: config.Transport,
+			FeedURL:   config.Options["feed"],
+		}), nil
+	})
+}
+
+// Results source of tfrrs, discovering meets from its rss feed of recent results
+type Source struct {
+	config    Config
+	transport *fetch.Transport
+	client    *http.Client
+	logger    *log.Logger
+}
+
+func NewSource(config Config) *Source {
+	transport := config.transport()
+	if len(config.FeedURL) == 0 {
+		config.FeedURL = feedURL
+	}
+	return &Source{
+		config:    config,
+		transport: transport,
+		client:    transport.Client(),
+		logger:    log.New(os.Stdout, "TFRRS ", log.Ldate|log.Ltime),
+	}
+}
+
+func (s *Source) get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return s.client.Do(req)
+}
+
+// Meets listed in the rss feed. Items that do not list a meet are logged and skipped
+func (s *Source) Discover(ctx context.Context) ([]scrapers.MeetRef, error) {
+	s.logger.Println("Looking at meet RSS", s.config.FeedURL)
+	resp, err := s.get(ctx, s.config.FeedURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	doc, err := xmlquery.Parse(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var meets []scrapers.MeetRef
+	for _, item := range xmlquery.Find(doc, "//item") {
+		t := xmlquery.Find(item, "/title")
+		d := xmlquery.Find(item, "/description")
+		l := xmlquery.Find(item, "/link")
+		if len(t) != 1 || len(d) != 1 || len(l) != 1 {
+			s.logger.Println("Encountered malformed xml item for meet, not scraping")
+			continue
+		}
+		meet, err := parseFeedItem(t[0].InnerText(), d[0].InnerText(), l[0].InnerText())
+		if err != nil {
+			s.logger.Println("Skipping meet:", err)
+			continue
+		}
+		meets = append(meets, meet)
+	}
+	return meets, nil
+}
+
+func (s *Source) Fetch(ctx context.Context, meet scrapers.MeetRef) (scrapers.Page, error) {
+	resp, err := s.get(ctx, meet.URL)
+	if err != nil {
+		return scrapers.Page{}, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return scrapers.Page{}, err
+	}
+	if resp.StatusCode >= 400 {
+		return scrapers.Page{}, fmt.Errorf("fetching meet %s: %s", meet.URL, resp.Status)
+	}
+	return scrapers.Page{URL: meet.URL, Status: resp.StatusCode, Header: resp.Header, Body: body}, nil
+}
+
+// Parse a meet page, following the links of its athletes and schools. When backfilling, the meets listed on the
+// pages of new athletes are returned
+func (s *Source) Parse(ctx context.Context, tx *sql.Tx, meetID uint32, ref scrapers.MeetRef, page scrapers.Page) ([]scrapers.MeetRef, error) {
+	meet := internal.Meet{
+		ID:      meetID,
+		Name:    ref.Name,
+		Date:    ref.Date,
+		EndDate: ref.EndDate,
+		Season:  parseSeason(ref.URL, ref.Date, nil),
+	}
+	if err := database.InsertMeet(tx, meet); err != nil {
+		return nil, err
+	}
+
+	var found []scrapers.MeetRef
+	meetCtx := colly.NewContext()
+	meetCtx.Put("MeetID", meetID)
+	meetCtx.Put("Meet", &meet)
+	meetCtx.Put("tx", tx)
+	// only the meets a scrape starts from queue the histories of their new athletes, so that a backfill does not crawl
+	// on from teammate to teammate
+	if s.config.Backfill && !ref.Queued {
+		meetCtx.Put("backfill", &found)
+	}
+	meetCollector := newMeetCollector(ctx, pageTransport{page: page, next: s.transport}, s.client)
+	if err := meetCollector.Request("GET", page.URL, nil, meetCtx, nil); err != nil {
+		return nil, err
+	}
+	// rows that may resolve on a later pass leave the meet to be scraped then, rather than stored without them
+	if failed, _ := meetCtx.GetAny("transient").(int); failed > 0 {
+		return nil, fmt.Errorf("could not resolve %d rows of meet %s for now, leaving it to a later pass", failed, ref.Key)
+	}
+	// the meet is only refreshed once every row resolved, so that none of its stored results is removed by mistake
+	if unresolved, _ := meetCtx.GetAny("unresolved").(int); unresolved > 0 {
+		if _, stored := database.GetMeetRelation(tx, ref.Key); stored {
+			return nil, fmt.Errorf("could not resolve %d rows of meet %s, keeping its stored results", unresolved, ref.Key)
+		}
+	}
+
+	// meets scraped by ID or from the archive are only dated by their page
+	if meet.Date.IsZero() {
+		return nil, fmt.Errorf("could not find the date of meet %s", ref.Key)
+	}
+
+	// track meets are only known to be indoor or outdoor once their events are read
+	if err := database.SetMeetSeason(tx, meetID, parseSeason(ref.URL, meet.Date, database.GetMeetEventTypes(tx, meetID))); err != nil {
+		return nil, err
+	}
+	return found, nil
+}
+
+// Serves a fetched page to the meet collector, fetching any other url through next
+type pageTransport struct {
+	page scrapers.Page
+	next http.RoundTripper
+}
+
+func (t pageTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.String() != t.page.URL {
+		return t.next.RoundTrip(req)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", t.page.Status, http.StatusText(t.page.Status)),
+		StatusCode:    t.page.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        t.page.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(t.page.Body)),
+		ContentLength: int64(len(t.page.Body)),
+		Request:       req,
+	}, nil
+}
@@ -0,0 +1,114 @@
+package tfrrs
+
+import (
+	"bactic/internal/scrapers"
+	"bactic/internal/scrapers/fetch"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDiscover(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "../../../test/tfrrs_test.rss")
+	}))
+	defer server.Close()
+
+	source := NewSource(Config{FeedURL: server.URL, Transport: fetch.NewTransport(fetch.Config{IgnoreRobots: true})})
+	meets, err := source.Discover(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []scrapers.MeetRef{
+		{
+			Key:     "tfrrs/xc/23218",
+			Name:    "2023 SCIAC Cross Country Championships",
+			URL:     "https://tfrrs.org/results/xc/23218/2023_SCIAC_Cross_Country_Championships",
+			Date:    time.Date(2023, time.October, 28, 0, 0, 0, 0, time.UTC),
+			EndDate: time.Date(2023, time.October, 28, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			Key:     "tfrrs/tf/79700",
+			Name:    "2023 SCIAC TF Championships",
+			URL:     "https://tfrrs.org/results/79700/m/2023_SCIAC_TF_Championships",
+			Date:    time.Date(2023, time.April, 29, 0, 0, 0, 0, time.UTC),
+			EndDate: time.Date(2023, time.April, 29, 0, 0, 0, 0, time.UTC),
+		},
+	}
+	if len(meets) != len(expected) {
+		t.Fatalf("Expected %d meets but got %+v", len(expected), meets)
+	}
+	for i, meet := range meets {
+		if meet.Key != expected[i].Key || meet.Name != expected[i].Name || meet.URL != expected[i].URL ||
+			!meet.Date.Equal(expected[i].Date) || !meet.EndDate.Equal(expected[i].EndDate) {
+			t.Errorf("Expected meet %+v but got %+v", expected[i], meet)
+		}
+	}
+
+	if _, err = parseFeedItem("Bactic Championships", "TBD", "https://tfrrs.org/results/79700"); err == nil {
+		t.Error("Expected an error for an item without dates")
+	}
+	if _, err = parseFeedItem("Bactic Championships", "April 29, 2023", "https://tfrrs.org/teams/7"); err == nil {
+		t.Error("Expected an error for an item without a meet link")
+	}
+}
+
+func TestTransient(t *testing.T) {
+	errs := []struct {
+		err       error
+		transient bool
+	}{
+		{&statusError{url: "https://www.tfrrs.org/athletes/1", status: http.StatusServiceUnavailable}, true},
+		{&statusError{url: "https://www.tfrrs.org/athletes/1", status: http.StatusTooManyRequests}, true},
+		{&statusError{url: "https://www.tfrrs.org/athletes/1", status: http.StatusNotFound}, false},
+		{fmt.Errorf("fetching https://www.tfrrs.org/athletes/1: %w", fetch.ErrDisallowed), false},
+		{fmt.Errorf("replaying https://www.tfrrs.org/athletes/1: %w", fetch.ErrNotArchived), false},
+		{io.ErrUnexpectedEOF, true},
+	}
+	for _, e := range errs {
+		if transient(e.err) != e.transient {
+			t.Errorf("Expected %v to be transient: %t", e.err, e.transient)
+		}
+	}
+}
+
+func TestPageTransport(t *testing.T) {
+	var fetched []string
+	next := roundTripper(func(req *http.Request) (*http.Response, error) {
+		fetched = append(fetched, req.URL.String())
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+	})
+	page := scrapers.Page{URL: "https://tfrrs.org/results/79700", Status: http.StatusOK, Body: []byte("<h3>Results</h3>")}
+	client := &http.Client{Transport: pageTransport{page: page, next: next}}
+
+	resp, err := client.Get(page.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != "<h3>Results</h3>" || len(fetched) != 0 {
+		t.Errorf("Expected the fetched page to be served without a request but got %q after fetching %v", body, fetched)
+	}
+
+	if resp, err = client.Get("https://tfrrs.org/athletes/7"); err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if len(fetched) != 1 {
+		t.Errorf("Expected other pages to be fetched through the next transport but fetched %v", fetched)
+	}
+}
+
+type roundTripper func(*http.Request) (*http.Response, error)
+
+func (f roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
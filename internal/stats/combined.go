@@ -0,0 +1,92 @@
+package stats
+
+import (
+	"bactic/internal"
+	"fmt"
+	"math"
+)
+
+// Coefficients of the combined event scoring formulas. Track events score A*(B-T)^C for a time T in
+// seconds, and field events score A*(M-B)^C for a mark M in meters, or centimeters for jumps.
+type scoringCoefficients struct {
+	A, B, C float64
+}
+
+// Official scoring tables, keyed by combined event and then by the component discipline. The indoor heptathlon is
+// contested by men and the pentathlon by women
+var scoringTables = map[internal.EventType]map[internal.EventType]scoringCoefficients{
+	internal.DEC: {
+		internal.T100M:     {25.4347, 18, 1.81},
+		internal.LONG_JUMP: {0.14354, 220, 1.4},
+		internal.SHOT:      {51.39, 1.5, 1.05},
+		internal.HIGH_JUMP: {0.8465, 75, 1.42},
+		internal.T400M:     {1.53775, 82, 1.81},
+		internal.T110H:     {5.74352, 28.5, 1.92},
+		internal.DISCUS:    {12.91, 4, 1.1},
+		internal.VAULT:     {0.2797, 100, 1.35},
+		internal.JAV:       {10.14, 7, 1.08},
+		internal.T1500M:    {0.03768, 480, 1.85},
+	},
+	internal.HEPT: {
+		internal.T100H:     {9.23076, 26.7, 1.835},
+		internal.HIGH_JUMP: {1.84523, 75, 1.348},
+		internal.SHOT:      {56.0211, 1.5, 1.05},
+		internal.T200M:     {4.99087, 42.5, 1.81},
+		internal.LONG_JUMP: {0.188807, 210, 1.41},
+		internal.JAV:       {15.9803, 3.8, 1.04},
+		internal.T800M:     {0.11193, 254, 1.88},
+	},
+	internal.PENT: {
+		internal.T60H:      {20.0479, 17, 1.835},
+		internal.HIGH_JUMP: {1.84523, 75, 1.348},
+		internal.SHOT:      {56.0211, 1.5, 1.05},
+		internal.LONG_JUMP: {0.188807, 210, 1.41},
+		internal.T800M:     {0.11193, 254, 1.88},
+	},
+	internal.HEPT_INDOOR: {
+		internal.T60M:      {58.015, 11.5, 1.81},
+		internal.LONG_JUMP: {0.14354, 220, 1.4},
+		internal.SHOT:      {51.39, 1.5, 1.05},
+		internal.HIGH_JUMP: {0.8465, 75, 1.42},
+		internal.T60H:      {20.5173, 15.5, 1.92},
+		internal.VAULT:     {0.2797, 100, 1.35},
+		internal.T1000M:    {0.08713, 305.5, 1.85},
+	},
+}
+
+// Jumps are scored in centimeters rather than meters
+var scoredInCentimeters = map[internal.EventType]bool{
+	internal.HIGH_JUMP: true,
+	internal.VAULT:     true,
+	internal.LONG_JUMP: true,
+}
+
+// Compute the points a mark (seconds or meters) scores for a discipline of a combined event
+func CombinedPoints(combined internal.EventType, component internal.EventType, mark float32) (int, error) {
+	table, found := scoringTables[combined]
+	if !found {
+		return 0, fmt.Errorf("event %d is not a scored combined event", combined)
+	}
+	coef, found := table[component]
+	if !found {
+		return 0, fmt.Errorf("event %d is not a discipline of combined event %d", component, combined)
+	}
+
+	// marks are measured to the centimeter or hundredth, so round away the float32 representation error
+	var diff float64
+	if component.IsField() {
+		cm := math.Round(float64(mark) * 100)
+		if scoredInCentimeters[component] {
+			diff = cm - coef.B
+		} else {
+			diff = cm/100 - coef.B
+		}
+	} else {
+		diff = coef.B - math.Round(float64(mark)*100)/100
+	}
+	if diff <= 0 {
+		return 0, nil
+	}
+	// the tables truncate to whole points
+	return int(math.Floor(coef.A * math.Pow(diff, coef.C))), nil
+}
@@ -0,0 +1,42 @@
+package stats_test
+
+import (
+	"bactic/internal"
+	"bactic/internal/stats"
+	"testing"
+)
+
+func TestCombinedPoints(t *testing.T) {
+	marks := []struct {
+		combined  internal.EventType
+		component internal.EventType
+		mark      float32
+		points    int
+	}{
+		{internal.DEC, internal.T100M, 10.40, 999},
+		{internal.DEC, internal.LONG_JUMP, 7.76, 1000},
+		{internal.DEC, internal.HIGH_JUMP, 2.20, 992},
+		{internal.DEC, internal.T1500M, 233.79, 1000},
+		{internal.HEPT, internal.T100H, 13.85, 1000},
+		{internal.HEPT, internal.SHOT, 17.07, 1000},
+		{internal.HEPT, internal.T800M, 127.00, 1009},
+		{internal.DEC, internal.T100M, 19.0, 0},
+		{internal.HEPT_INDOOR, internal.T60M, 6.76, 969},
+		{internal.HEPT_INDOOR, internal.T60H, 7.68, 1064},
+		{internal.HEPT_INDOOR, internal.T1000M, 152.5, 959},
+		{internal.PENT, internal.T60H, 8.22, 1079},
+	}
+	for _, m := range marks {
+		points, err := stats.CombinedPoints(m.combined, m.component, m.mark)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if points != m.points {
+			t.Errorf("Mark %f in event %d scored %d, expected %d", m.mark, m.component, points, m.points)
+		}
+	}
+
+	if _, err := stats.CombinedPoints(internal.HEPT, internal.DISCUS, 40); err == nil {
+		t.Error("Expected the discus to not be a heptathlon discipline")
+	}
+}
@@ -0,0 +1,45 @@
+package stats
+
+import "bactic/internal"
+
+// Compare two vertical jump progressions that finished at the same height using the count-back rules.
+// The athlete with fewer misses at the last cleared height places higher, followed by the athlete with
+// fewer misses over the whole competition. Returns a negative number if a places ahead of b, a positive
+// number if b places ahead of a, and zero if they remain tied.
+func BreakVerticalTie(a, b []internal.HeightAttempt) int {
+	lastA, totalA := countBack(a)
+	lastB, totalB := countBack(b)
+	if lastA != lastB {
+		return lastA - lastB
+	}
+	return totalA - totalB
+}
+
+// Misses at the last cleared height, and total misses up to and including it. Heights are in ascending bar order
+func countBack(heights []internal.HeightAttempt) (last int, total int) {
+	var running int
+	for _, h := range heights {
+		running += h.Misses()
+		if h.Cleared() {
+			last = h.Misses()
+			total = running
+		}
+	}
+	return last, total
+}
+
+// Fraction of attempts in a progression that cleared the bar. Passes are not counted as attempts
+func ClearanceEfficiency(heights []internal.HeightAttempt) float32 {
+	var clearances, attempts int
+	for _, h := range heights {
+		attempts += h.Misses()
+		if h.Cleared() {
+			clearances++
+			attempts++
+		}
+	}
+	if attempts == 0 {
+		return 0
+	}
+	return float32(clearances) / float32(attempts)
+}
@@ -0,0 +1,39 @@
+package stats_test
+
+import (
+	"bactic/internal"
+	"bactic/internal/stats"
+	"testing"
+)
+
+func progression(attempts ...string) []internal.HeightAttempt {
+	heights := make([]internal.HeightAttempt, len(attempts))
+	for i, a := range attempts {
+		heights[i] = internal.HeightAttempt{Height: 1.70 + 0.05*float32(i), Attempts: a}
+	}
+	return heights
+}
+
+func TestBreakVerticalTie(t *testing.T) {
+	// fewer misses at the last cleared height wins
+	if stats.BreakVerticalTie(progression("XO", "O", "XXX"), progression("O", "XO", "XXX")) >= 0 {
+		t.Error("Expected the first athlete to win on misses at the final height")
+	}
+	// then fewer total misses
+	if stats.BreakVerticalTie(progression("XXO", "O", "XXX"), progression("-", "O", "XXX")) <= 0 {
+		t.Error("Expected the second athlete to win on total misses")
+	}
+	// misses above the last cleared height do not count
+	if stats.BreakVerticalTie(progression("O", "O", "XXX"), progression("O", "O", "X-")) != 0 {
+		t.Error("Expected the athletes to remain tied")
+	}
+}
+
+func TestClearanceEfficiency(t *testing.T) {
+	if eff := stats.ClearanceEfficiency(progression("-", "O", "XO", "XXX")); eff != 2.0/6.0 {
+		t.Errorf("Expected an efficiency of 1/3 but got %f", eff)
+	}
+	if eff := stats.ClearanceEfficiency(nil); eff != 0 {
+		t.Errorf("Expected no efficiency for an empty progression but got %f", eff)
+	}
+}
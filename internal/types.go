@@ -2,6 +2,8 @@ package internal
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 )
 
@@ -20,6 +22,55 @@ const (
 	OUTDOOR = iota
 )
 
+// Indoor track types. Marks on flat and oversized tracks are not directly comparable with banked track marks
+const (
+	UNKNOWN_TRACK   = iota
+	FLAT_TRACK      = iota
+	BANKED_TRACK    = iota
+	OVERSIZED_TRACK = iota
+)
+
+var trackTypeToStr = map[int]string{
+	UNKNOWN_TRACK:   "Unknown",
+	FLAT_TRACK:      "Flat",
+	BANKED_TRACK:    "Banked",
+	OVERSIZED_TRACK: "Oversized",
+}
+
+// Class years
+const (
+	UNKNOWN_CLASS = iota
+	FRESHMAN      = iota
+	SOPHOMORE     = iota
+	JUNIOR        = iota
+	SENIOR        = iota
+	FIFTH_YEAR    = iota
+	GRADUATE      = iota
+)
+
+var classToStr = map[int]string{
+	UNKNOWN_CLASS: "Unknown",
+	FRESHMAN:      "FR",
+	SOPHOMORE:     "SO",
+	JUNIOR:        "JR",
+	SENIOR:        "SR",
+	FIFTH_YEAR:    "5TH",
+	GRADUATE:      "GR",
+}
+
+// Event sexes
+const (
+	UNKNOWN_SEX = iota
+	MEN         = iota
+	WOMEN       = iota
+)
+
+var sexToStr = map[int]string{
+	UNKNOWN_SEX: "Unknown",
+	MEN:         "Men",
+	WOMEN:       "Women",
+}
+
 var divisionToStr = map[int]string{
 	DIII: "DIII",
 	DII:  "DII",
@@ -58,6 +109,22 @@ const (
 	XC_6K       = EventType(iota)
 	XC_8K       = EventType(iota)
 	XC_10K      = EventType(iota)
+	T60M        = EventType(iota)
+	T60H        = EventType(iota)
+	T300M       = EventType(iota)
+	T500M       = EventType(iota)
+	T600M       = EventType(iota)
+	T1000M      = EventType(iota)
+	MILE        = EventType(iota)
+	DMR         = EventType(iota)
+	WEIGHT      = EventType(iota)
+	PENT        = EventType(iota)
+	HEPT_INDOOR = EventType(iota)
+	XC_5K       = EventType(iota)
+	T1600M      = EventType(iota)
+	T3200M      = EventType(iota)
+	T300H       = EventType(iota)
+	T4X800      = EventType(iota)
 )
 
 var eventToStr = map[EventType]string{
@@ -88,17 +155,219 @@ var eventToStr = map[EventType]string{
 	XC_10K:      "XC 10K",
 	XC_8K:       "XC 8K",
 	XC_6K:       "XC 6K",
+	T60M:        "60m",
+	T60H:        "60 Hurdles",
+	T300M:       "300m",
+	T500M:       "500m",
+	T600M:       "600m",
+	T1000M:      "1000m",
+	MILE:        "Mile",
+	DMR:         "Distance Medley Relay",
+	WEIGHT:      "Weight Throw",
+	PENT:        "Pentathlon",
+	HEPT_INDOOR: "Indoor Heptathlon",
+	XC_5K:       "XC 5K",
+	T1600M:      "1600m",
+	T3200M:      "3200m",
+	T300H:       "300 Hurdles",
+	T4X800:      "4x800",
+}
+
+// Events whose marks are measured in meters rather than seconds
+var fieldEvents = map[EventType]bool{
+	HIGH_JUMP:   true,
+	VAULT:       true,
+	LONG_JUMP:   true,
+	TRIPLE_JUMP: true,
+	SHOT:        true,
+	DISCUS:      true,
+	HAMMER:      true,
+	JAV:         true,
+	WEIGHT:      true,
+}
+
+// Whether the event is a jump or throw, measured in meters
+func (e EventType) IsField() bool {
+	return fieldEvents[e]
 }
 
-// Event stages
+// Whether the event is a combined event, scored in points
+func (e EventType) IsCombined() bool {
+	return e == DEC || e == HEPT || e == PENT || e == HEPT_INDOOR
+}
+
+// Whether a larger quantity is a better mark in the event
+func (e EventType) HigherIsBetter() bool {
+	return e.IsField() || e.IsCombined()
+}
+
+// Events whose marks are affected by a wind reading
+var windEvents = map[EventType]bool{
+	T100M:       true,
+	T200M:       true,
+	T100H:       true,
+	T110H:       true,
+	LONG_JUMP:   true,
+	TRIPLE_JUMP: true,
+}
+
+// Whether marks in the event are wind-legal only under the wind limit
+func (e EventType) HasWind() bool {
+	return windEvents[e]
+}
+
+// Events whose marks are affected by a wind reading, in ascending order
+func WindEvents() []EventType {
+	events := make([]EventType, 0, len(windEvents))
+	for e := range windEvents {
+		events = append(events, e)
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i] < events[j] })
+	return events
+}
+
+// Events contested in only one season, which place a meet in that season
+var eventSeasons = map[EventType]int{
+	XC_5K:       XC,
+	XC_6K:       XC,
+	XC_8K:       XC,
+	XC_10K:      XC,
+	T60M:        INDOOR,
+	T60H:        INDOOR,
+	T300M:       INDOOR,
+	T500M:       INDOOR,
+	T600M:       INDOOR,
+	T1000M:      INDOOR,
+	MILE:        INDOOR,
+	DMR:         INDOOR,
+	WEIGHT:      INDOOR,
+	PENT:        INDOOR,
+	HEPT_INDOOR: INDOOR,
+	T100M:       OUTDOOR,
+	T100H:       OUTDOOR,
+	T110H:       OUTDOOR,
+	T400H:       OUTDOOR,
+	T300H:       OUTDOOR,
+	T3000S:      OUTDOOR,
+	T10000M:     OUTDOOR,
+	T4X100:      OUTDOOR,
+	DISCUS:      OUTDOOR,
+	HAMMER:      OUTDOOR,
+	JAV:         OUTDOOR,
+	DEC:         OUTDOOR,
+	HEPT:        OUTDOOR,
+}
+
+// Infer the season of a meet from the events contested at it. Meets with only events held in every season, such as
+// the 400m or long jump, fall back on the date: indoor over the winter and outdoor in the spring. Track meets held in
+// the fall open the indoor season, so only meets with no known events are placed in cross country by their date
+func InferSeason(events []EventType, date time.Time) int {
+	counts := make(map[int]int)
+	for _, e := range events {
+		if season, found := eventSeasons[e]; found {
+			counts[season]++
+		}
+	}
+	if len(counts) > 0 {
+		season, most := OUTDOOR, 0
+		for _, s := range []int{XC, INDOOR, OUTDOOR} {
+			if counts[s] > most {
+				season, most = s, counts[s]
+			}
+		}
+		return season
+	}
+
+	switch month := date.Month(); {
+	case month >= time.September && month <= time.November && len(events) == 0:
+		return XC
+	case month >= time.September || month <= time.February || (month == time.March && date.Day() <= 15):
+		return INDOOR
+	}
+	return OUTDOOR
+}
+
+// Largest tailwind in m/s under which a mark is wind-legal
+const WIND_LIMIT = 2.0
+
+// Timing methods. Fully automatic timing (FAT) is read to the hundredth, while hand times are read from a stopwatch
 const (
-	PRELIM = iota
-	FINAL  = iota
+	FAT  = iota
+	HAND = iota
+)
+
+var timingToStr = map[int]string{
+	FAT:  "FAT",
+	HAND: "Hand",
+}
+
+// Seconds added to a hand time to compare it with FAT times. Hand times in races longer than 400m are compared as is
+var handTimingOffsets = map[EventType]float32{
+	T100M:  0.24,
+	T200M:  0.24,
+	T100H:  0.24,
+	T110H:  0.24,
+	T4X100: 0.24,
+	T400M:  0.14,
+	T400H:  0.14,
+	T300H:  0.14,
+	T4X400: 0.14,
+	T60M:   0.24,
+	T60H:   0.24,
+	T300M:  0.14,
+}
+
+// Seconds added to a hand time in the event to convert it to a FAT equivalent
+func (e EventType) HandTimingOffset() float32 {
+	return handTimingOffsets[e]
+}
+
+// Convert a time in the event to its FAT equivalent, so that hand and FAT times can be compared
+func (e EventType) FATEquivalent(quantity float32, timing int) float32 {
+	if timing == HAND {
+		return quantity + e.HandTimingOffset()
+	}
+	return quantity
+}
+
+// Event stages. Events contested in a single round are finals
+const (
+	PRELIM    = iota
+	FINAL     = iota
+	SEMIFINAL = iota
 )
 
 var stageToString = map[int]string{
-	PRELIM: "Prelim",
-	FINAL:  "Final",
+	PRELIM:    "Prelim",
+	FINAL:     "Final",
+	SEMIFINAL: "Semifinal",
+}
+
+// Result statuses. Any status other than VALID has no recorded quantity
+const (
+	VALID       = iota
+	FOUL        = iota
+	PASS        = iota
+	NO_HEIGHT   = iota
+	NO_DISTANCE = iota
+	DNF         = iota
+	DNS         = iota
+	DQ          = iota
+	FS          = iota
+	NT          = iota
+)
+
+var statusToStr = map[int]string{
+	VALID:       "Valid",
+	FOUL:        "FOUL",
+	PASS:        "PASS",
+	NO_HEIGHT:   "NH",
+	NO_DISTANCE: "ND",
+	DNF:         "DNF",
+	DNS:         "DNS",
+	DQ:          "DQ",
+	FS:          "FS",
+	NT:          "NT",
 }
 
 // Timing errors
@@ -116,12 +385,86 @@ type Result struct {
 	HeatID    uint32
 	AthleteID uint32
 	Place     int
-	// Either time in seconds or meters for distance respective of the event type
+	// Either time in seconds, meters for distance, or points for combined events respective of the event type
 	Quantity float32
-	WindMS   float32
-	Stage    int
-	Team     string
-	Members  []uint32
+	// Wind reading of the athlete's mark, or of the heat, nil when none was recorded
+	WindMS *float32
+	Stage  int
+	Status int
+	// Rule code of a disqualification, if listed
+	DQCode string
+	// Timing method of a timed result
+	Timing int
+	// School the result was recorded for
+	SchoolID uint32
+	// Relay designation (A, B, ...), leg athletes in running order and their splits if recorded
+	Team       string
+	Members    []uint32
+	Splits     []float32
+	Attempts   []Attempt
+	Heights    []HeightAttempt
+	Components []CombinedComponent
+	// Version of the result, counting up from 1 with each correction posted after it was first scraped
+	Version int
+}
+
+// A value of a result changed by a correction. Values of removed results are logged with an empty new value
+type ResultChange struct {
+	ResultID uint32
+	Version  int
+	Field    string
+	// Stored values as text, empty when null
+	Old     string
+	New     string
+	Changed time.Time
+}
+
+// A single trial in a field event series
+type Attempt struct {
+	ResultID uint32
+	Number   int
+	// Meters, only recorded when the status is VALID
+	Quantity float32
+	// Wind reading for the attempt, nil when none was recorded
+	WindMS *float32
+	Status int
+}
+
+// Attempts at a single bar height in a vertical jump progression
+type HeightAttempt struct {
+	ResultID uint32
+	// Bar height in meters
+	Height float32
+	// Attempt sequence at the height as listed, such as O, XO, XXX, or - for a pass
+	Attempts string
+}
+
+// Whether the bar was cleared at this height
+func (h HeightAttempt) Cleared() bool {
+	return strings.HasSuffix(h.Attempts, "O")
+}
+
+// Number of failed attempts at this height
+func (h HeightAttempt) Misses() int {
+	return strings.Count(h.Attempts, "X")
+}
+
+// A single discipline within a combined event result
+type CombinedComponent struct {
+	ResultID uint32
+	Type     EventType
+	// Seconds or meters, only recorded when the status is VALID
+	Quantity float32
+	// Points as reported by the results source
+	Points int
+	// Points recomputed from the mark with the official scoring tables
+	ComputedPoints int
+	Status         int
+}
+
+// Whether the result's mark was set with a tailwind over the limit
+func (m *Result) WindAided() bool {
+	return m.WindMS != nil && *m.WindMS > WIND_LIMIT
 }
 
 // TODO: implement
@@ -129,10 +472,36 @@ func (m *Result) String() string {
 	return "TODO: implement result String()"
 }
 
+// A team's standing in a scored race, such as cross country
+type TeamResult struct {
+	ID       uint32
+	HeatID   uint32
+	SchoolID uint32
+	Place    int
+	Score    int
+	// Places of the scoring runners, followed by the places of the displacers
+	Scorers    []int64
+	Displacers []int64
+	// Team times in seconds, where listed
+	TotalTime float32
+	AvgTime   float32
+	// Gap between the first and fifth scorers
+	Spread float32
+}
+
 type Heat struct {
 	ID     uint32
 	Type   EventType
 	MeetID uint32
+	Sex    int
+	Stage  int
+	// Wind reading for the whole heat, nil when none was recorded
+	WindMS *float32
+	// Heat number within a prelim round, and section number within a timed final. Zero if not listed
+	Number  int
+	Section int
+	// Day the heat was run, zero if not listed, in which case it is taken to be run on the meet's first day
+	Date time.Time
 }
 
 type School struct {
@@ -147,11 +516,51 @@ type Meet struct {
 	ID     uint32
 	Name   string
 	Season int
-	Date   time.Time
+	// First and last day of the meet. Single-day meets end on their start date
+	Date    time.Time
+	EndDate time.Time
+	Venue   string
+	City    string
+	State   string
+	// School hosting the meet as listed, and its ID if known
+	Host         string
+	HostSchoolID uint32
+	// Track surface and lap length in meters, if listed
+	Surface   string
+	TrackSize int
+	// Indoor track the meet was contested on, if known
+	TrackType     int
+	TimingCompany string
 }
 
 type Athlete struct {
 	ID      uint32
 	Name    string
 	Schools []uint32 // athelete can be part of multiple schools
+	// Class year as listed on the athlete's profile, whether they are a redshirt, and their year of eligibility (the 2
+	// of SO-2), zero if not listed
+	Year        int
+	Redshirt    bool
+	Eligibility int
+	Hometown    string
+	Rosters     []Roster
+}
+
+// An athlete's place on a school's roster for a season. The class year is estimated back from the athlete's current
+// class year, so it is unknown for seasons before their first
+type Roster struct {
+	AthleteID uint32
+	SchoolID  uint32
+	// Calendar year of the season, as in 2023 Outdoor or 2022 Cross Country
+	Year      int
+	Season    int
+	ClassYear int
+}
+
+// The academic year a season falls in, named by the year it ends. Cross country opens the academic year
+func AcademicYear(year int, season int) int {
+	if season == XC {
+		return year + 1
+	}
+	return year
 }